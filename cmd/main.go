@@ -4,10 +4,13 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"github.com/sandlayth/supplier-api/handler"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
 	"github.com/sandlayth/supplier-api/repository"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -23,36 +26,132 @@ func main() {
 	userRepo := repository.NewUserMongoRepository(db)
 	locationRepo := repository.NewLocationMongoRepository(db)
 	supplierRepo := repository.NewSupplierMongoRepository(db)
-	purchaseRepo := repository.NewPurchaseMongoRepository(db)
+	contractPolicy := helper.GetEnv("CONTRACT_POLICY", model.ContractPolicyFlag)
+	if contractPolicy != model.ContractPolicyFlag && contractPolicy != model.ContractPolicyBlock {
+		log.Fatalf("Invalid CONTRACT_POLICY %q, expected %s or %s\n", contractPolicy, model.ContractPolicyFlag, model.ContractPolicyBlock)
+	}
+	purchaseRepo := repository.NewPurchaseMongoRepository(db, contractPolicy)
+
+	receiptRepo := repository.NewReceiptMongoRepository(db)
+	returnRepo := repository.NewReturnMongoRepository(db)
+	invoiceRepo := repository.NewInvoiceMongoRepository(db, helper.GetEnvFloat("INVOICE_TOLERANCE", 0.02))
+	contractRepo := repository.NewContractMongoRepository(db)
+	scorecardRepo := repository.NewScorecardMongoRepository(db)
+	rfqRepo := repository.NewRFQMongoRepository(db, purchaseRepo)
+	organisationRepo := repository.NewOrganisationMongoRepository(db)
+	auditRepo := repository.NewAuditMongoRepository(db)
+	retentionRepo := repository.NewRetentionMongoRepository(db)
+	idempotencyRepo := repository.NewIdempotencyMongoRepository(db, helper.GetEnvDuration("IDEMPOTENCY_WINDOW", 24*time.Hour), helper.GetEnvDuration("IDEMPOTENCY_LEASE", time.Minute))
+	unitOfWork := repository.NewMongoUnitOfWork(db)
+
+	trustedProxies, err := helper.ParseTrustedProxies(helper.GetEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v\n", err)
+	}
+	helper.TrustedProxies = trustedProxies
+
+	// Reject the tokens of the sessions revoked by a password change
+	helper.SessionCheck = userRepo.CheckSession
+	// Replay the responses to the retried requests of authenticated users, whose keys are kept apart
+	helper.AuthenticatedMiddleware = handler.IdempotencyMiddleware(idempotencyRepo)
 
 	// Initialize the handlers
 	userHandler := handler.NewUserHandler(userRepo)
-	locationHandler := handler.NewLocationHandler(locationRepo)
+	locationHandler := handler.NewLocationHandler(locationRepo, unitOfWork)
 	supplierHandler := handler.NewSupplierHandler(supplierRepo)
 	purchaseHandler := handler.NewPurchaseHandler(purchaseRepo)
+	receiptHandler := handler.NewReceiptHandler(receiptRepo)
+	returnHandler := handler.NewReturnHandler(returnRepo)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceRepo)
+	contractHandler := handler.NewContractHandler(contractRepo)
+	scorecardWindow := helper.GetEnvDuration("SCORECARD_WINDOW", 90*24*time.Hour)
+	scorecardHandler := handler.NewScorecardHandler(scorecardRepo, scorecardWindow)
+	rfqHandler := handler.NewRFQHandler(rfqRepo)
+	organisationHandler := handler.NewOrganisationHandler(organisationRepo)
+	auditHandler := handler.NewAuditHandler(auditRepo)
 
 	// Initialize the router and add the routes
 	router := mux.NewRouter()
+	router.Use(helper.RequestIDMiddleware)
 	handler.AddUserRoutes(router, userHandler)
 	handler.AddLocationRoutes(router, locationHandler)
 	handler.AddSupplierRoutes(router, supplierHandler)
 	handler.AddPurchaseRoutes(router, purchaseHandler)
+	handler.AddReceiptRoutes(router, receiptHandler)
+	handler.AddReturnRoutes(router, returnHandler)
+	handler.AddInvoiceRoutes(router, invoiceHandler)
+	handler.AddContractRoutes(router, contractHandler)
+	handler.AddScorecardRoutes(router, scorecardHandler)
+	handler.AddRFQRoutes(router, rfqHandler)
+	handler.AddOrganisationRoutes(router, organisationHandler)
+	handler.AddAuditRoutes(router, auditHandler)
 
 	cors := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{
 			http.MethodPost,
 			http.MethodGet,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
 		},
-		AllowedHeaders:   []string{"*"},
-		})
+		AllowedHeaders: []string{"*"},
+		// Clients send the ETag back in If-Match to update or delete a record
+		ExposedHeaders: []string{"ETag"},
+	})
 
 	corsRouter := cors.Handler(router)
 
+	// Start the background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startReorderJob(ctx, purchaseRepo)
+	startScorecardJob(ctx, scorecardRepo, scorecardWindow)
+	startPurgeJob(ctx, retentionRepo)
+
 	// Start the HTTP server
 	log.Fatal(http.ListenAndServe(":8080", corsRouter))
 }
 
+// startReorderJob periodically creates draft purchases for the locations running out of stock.
+// The drafts are attributed to the user configured in SYSTEM_USER_ID; the job is disabled without it.
+func startReorderJob(ctx context.Context, purchaseRepo repository.PurchaseRepository) {
+	systemUserID := helper.GetEnv("SYSTEM_USER_ID", "")
+	if systemUserID == "" {
+		log.Println("SYSTEM_USER_ID is not set, automatic reorder is disabled")
+		return
+	}
+	interval := helper.GetEnvDuration("REORDER_INTERVAL", time.Hour)
+	go helper.Schedule(ctx, "reorder", interval, func() error {
+		drafts, err := purchaseRepo.CreateReorderDrafts(systemUserID)
+		if len(drafts) > 0 {
+			log.Printf("Created %d draft purchases for locations below their reorder point\n", len(drafts))
+		}
+		return err
+	})
+}
+
+// startScorecardJob periodically refreshes the scorecards of the suppliers over the given window.
+func startScorecardJob(ctx context.Context, scorecardRepo repository.ScorecardRepository, window time.Duration) {
+	interval := helper.GetEnvDuration("SCORECARD_INTERVAL", 24*time.Hour)
+	go helper.Schedule(ctx, "scorecards", interval, func() error {
+		return scorecardRepo.RefreshScorecards(window)
+	})
+}
+
+// startPurgeJob periodically removes for good the records deleted for longer than DELETED_RETENTION.
+func startPurgeJob(ctx context.Context, retentionRepo repository.RetentionRepository) {
+	retention := helper.GetEnvDuration("DELETED_RETENTION", 30*24*time.Hour)
+	interval := helper.GetEnvDuration("PURGE_INTERVAL", 24*time.Hour)
+	go helper.Schedule(ctx, "purge", interval, func() error {
+		purged, err := retentionRepo.PurgeDeleted(retention)
+		if purged > 0 {
+			log.Printf("Purged %d records deleted more than %s ago\n", purged, retention)
+		}
+		return err
+	})
+}
+
 func initDb() *mongo.Client {
 	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
 	client, err := mongo.Connect(context.Background(), clientOptions)
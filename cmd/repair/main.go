@@ -0,0 +1,28 @@
+// Command repair fixes the supplier, location and user names copied into other documents
+// which are out of date, for instance because they were written before names were propagated.
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/sandlayth/supplier-api/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+
+	repairRepo := repository.NewRepairMongoRepository(client.Database("supplier-api"))
+	repaired, err := repairRepo.RepairNames()
+	log.Printf("Fixed the names of %d documents\n", repaired)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
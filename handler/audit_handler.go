@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// AuditHandler handles HTTP requests related to the audit log.
+type AuditHandler struct {
+	ar repository.AuditRepository
+}
+
+// NewAuditHandler creates a new instance of AuditHandler.
+func NewAuditHandler(ar repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{ar: ar}
+}
+
+// ListAuditHandler handles requests to browse the audit log.
+// It can be filtered with the entity, entityId and actor query parameters.
+func (h *AuditHandler) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	entries, err := h.ar.WithScope(requestScope(r)).List(query.Get("entity"), query.Get("entityId"), query.Get("actor"))
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, entries)
+}
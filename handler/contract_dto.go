@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contractRequest is the body of the requests creating or replacing a contract.
+type contractRequest struct {
+	SupplierID           primitive.ObjectID     `json:"supplier" validate:"required"`
+	StartDate            time.Time              `json:"startDate" validate:"required"`
+	EndDate              time.Time              `json:"endDate" validate:"required"`
+	Prices               []contractPricePayload `json:"prices"`
+	MinimumOrderQuantity int                    `json:"minimumOrderQuantity" validate:"min=0"`
+	PaymentTerms         string                 `json:"paymentTerms"`
+}
+
+// contractPricePayload is the price a contract locks for a location, in requests and responses.
+type contractPricePayload struct {
+	LocationID primitive.ObjectID `json:"location" validate:"required"`
+	Price      float64            `json:"price" validate:"min=0"`
+}
+
+// newContractRequest returns the request replacing a contract by itself, for merge patches.
+func newContractRequest(contract *model.Contract) *contractRequest {
+	return &contractRequest{
+		SupplierID:           contract.SupplierID,
+		StartDate:            contract.StartDate,
+		EndDate:              contract.EndDate,
+		Prices:               newContractPricePayloads(contract.Prices),
+		MinimumOrderQuantity: contract.MinimumOrderQuantity,
+		PaymentTerms:         contract.PaymentTerms,
+	}
+}
+
+func (req *contractRequest) toModel() *model.Contract {
+	var prices []model.ContractPrice
+	for _, price := range req.Prices {
+		prices = append(prices, model.ContractPrice(price))
+	}
+	return &model.Contract{
+		SupplierID:           req.SupplierID,
+		StartDate:            req.StartDate,
+		EndDate:              req.EndDate,
+		Prices:               prices,
+		MinimumOrderQuantity: req.MinimumOrderQuantity,
+		PaymentTerms:         req.PaymentTerms,
+	}
+}
+
+func newContractPricePayloads(prices []model.ContractPrice) []contractPricePayload {
+	payloads := make([]contractPricePayload, 0, len(prices))
+	for _, price := range prices {
+		payloads = append(payloads, contractPricePayload(price))
+	}
+	return payloads
+}
+
+// contractResponse is a contract as the API returns it.
+type contractResponse struct {
+	ID                   primitive.ObjectID     `json:"id"`
+	TenantID             *primitive.ObjectID    `json:"tenant,omitempty"`
+	SupplierID           primitive.ObjectID     `json:"supplier"`
+	StartDate            time.Time              `json:"startDate"`
+	EndDate              time.Time              `json:"endDate"`
+	Prices               []contractPricePayload `json:"prices"`
+	MinimumOrderQuantity int                    `json:"minimumOrderQuantity"`
+	PaymentTerms         string                 `json:"paymentTerms"`
+	DeletedAt            *time.Time             `json:"deletedAt,omitempty"`
+	DeletedBy            *primitive.ObjectID    `json:"deletedBy,omitempty"`
+	Version              int                    `json:"version"`
+}
+
+func newContractResponse(contract *model.Contract) contractResponse {
+	return contractResponse{
+		ID:                   contract.ID,
+		TenantID:             optionalID(contract.TenantID),
+		SupplierID:           contract.SupplierID,
+		StartDate:            contract.StartDate,
+		EndDate:              contract.EndDate,
+		Prices:               newContractPricePayloads(contract.Prices),
+		MinimumOrderQuantity: contract.MinimumOrderQuantity,
+		PaymentTerms:         contract.PaymentTerms,
+		DeletedAt:            contract.DeletedAt,
+		DeletedBy:            optionalID(contract.DeletedBy),
+		Version:              contract.Version,
+	}
+}
+
+func newContractResponses(contracts []model.Contract) []contractResponse {
+	responses := make([]contractResponse, 0, len(contracts))
+	for i := range contracts {
+		responses = append(responses, newContractResponse(&contracts[i]))
+	}
+	return responses
+}
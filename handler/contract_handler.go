@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// defaultExpiryWindowDays is how far ahead the expiry report looks when no window is requested.
+const defaultExpiryWindowDays = 30
+
+// ContractHandler handles HTTP requests related to supplier contracts.
+type ContractHandler struct {
+	cr repository.ContractRepository
+}
+
+// NewContractHandler creates a new instance of ContractHandler.
+func NewContractHandler(cr repository.ContractRepository) *ContractHandler {
+	return &ContractHandler{cr: cr}
+}
+
+// CreateContractHandler handles requests to create a new contract.
+func (h *ContractHandler) CreateContractHandler(w http.ResponseWriter, r *http.Request) {
+	var request contractRequest
+	if !decodeRequest(w, r, "contract", &request) {
+		return
+	}
+	contract := request.toModel()
+
+	err := h.cr.WithScope(requestScope(r)).CreateContract(contract)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newContractResponse(contract))
+}
+
+// GetContractHandler handles requests to retrieve a contract by ID.
+func (h *ContractHandler) GetContractHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	contractID := params["id"]
+
+	contract, err := h.cr.WithScope(requestScope(r)).GetContractByID(contractID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, contract.Version)
+	helper.RespondJSON(w, newContractResponse(contract))
+}
+
+// UpdateContractHandler handles requests to update an existing contract.
+func (h *ContractHandler) UpdateContractHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	contractID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var request contractRequest
+	if !decodeRequest(w, r, "contract", &request) {
+		return
+	}
+	updatedContract := request.toModel()
+
+	err := h.cr.WithScope(requestScope(r)).UpdateContract(contractID, updatedContract, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, newContractResponse(updatedContract))
+}
+
+// PatchContractHandler handles requests to partially update an existing contract with a JSON merge patch.
+func (h *ContractHandler) PatchContractHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	contractID := params["id"]
+
+	contract, err := h.cr.WithScope(requestScope(r)).GetContractByID(contractID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !mergePatch(w, r, newContractRequest(contract)) {
+		return
+	}
+	h.UpdateContractHandler(w, r)
+}
+
+// DeleteContractHandler handles requests to delete a contract by ID.
+func (h *ContractHandler) DeleteContractHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	contractID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.cr.WithScope(requestScope(r)).DeleteContract(contractID, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Contract deleted successfully"})
+}
+
+// RestoreContractHandler handles requests to restore a deleted contract by ID.
+func (h *ContractHandler) RestoreContractHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	contractID := params["id"]
+
+	err := h.cr.WithScope(requestScope(r)).RestoreContract(contractID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Contract restored successfully"})
+}
+
+// ListBySupplierHandler handles requests to retrieve the contracts of a supplier.
+func (h *ContractHandler) ListBySupplierHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	contracts, err := h.cr.WithScope(requestScope(r)).ListBySupplier(supplierID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newContractResponses(contracts))
+}
+
+// ExpiringContractsHandler handles requests to list the contracts nearing expiry.
+// The window defaults to 30 days and can be changed with the days query parameter.
+func (h *ContractHandler) ExpiringContractsHandler(w http.ResponseWriter, r *http.Request) {
+	days := defaultExpiryWindowDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			respondMalformed(w, "invalid days parameter")
+			return
+		}
+		days = parsed
+	}
+
+	contracts, err := h.cr.WithScope(requestScope(r)).ListExpiring(time.Duration(days) * 24 * time.Hour)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newContractResponses(contracts))
+}
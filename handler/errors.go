@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// respondError responds with the problem details of an error returned by a repository: 404 Not Found
+// for a missing record, 422 Unprocessable Entity with the invalid fields for a validation failure,
+// 403 Forbidden for a write the user is not allowed to make, 409 Conflict when the state of the records
+// prevents the write, with the blocking records when a delete was refused, and 412 Precondition Failed
+// when the record was changed since the version the request expects. Other errors answer 500 without
+// their details, which are logged instead.
+func respondError(w http.ResponseWriter, err error) {
+	var (
+		notFound   *repository.NotFoundError
+		validation *repository.ValidationError
+		forbidden  *repository.ForbiddenError
+		conflict   *repository.ConflictError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		helper.RespondProblem(w, http.StatusNotFound, model.ErrorCodeNotFound, err.Error())
+	case errors.As(err, &validation):
+		helper.WriteProblem(w, model.Problem{
+			Status: http.StatusUnprocessableEntity,
+			Code:   model.ErrorCodeValidationFailed,
+			Detail: err.Error(),
+			Errors: validation.Fields,
+		})
+	case errors.As(err, &forbidden):
+		helper.RespondProblem(w, http.StatusForbidden, model.ErrorCodeForbidden, err.Error())
+	case errors.As(err, &conflict) && len(conflict.Blockers) > 0:
+		helper.WriteProblem(w, model.Problem{
+			Status:   http.StatusConflict,
+			Code:     model.ErrorCodeStillReferenced,
+			Detail:   err.Error(),
+			Blockers: conflict.Blockers,
+		})
+	case errors.As(err, &conflict):
+		helper.RespondProblem(w, http.StatusConflict, model.ErrorCodeConflict, err.Error())
+	case errors.Is(err, repository.ErrVersionMismatch):
+		helper.RespondProblem(w, http.StatusPreconditionFailed, model.ErrorCodeVersionMismatch, err.Error())
+	case errors.Is(err, repository.ErrTransactionsUnsupported):
+		helper.RespondProblem(w, http.StatusNotImplemented, model.ErrorCodeTransactionsUnsupported, err.Error())
+	default:
+		log.Printf("Internal error: %v\n", err)
+		helper.RespondProblem(w, http.StatusInternalServerError, model.ErrorCodeInternal, "an unexpected error occurred")
+	}
+}
+
+// respondMalformed responds 400 Bad Request to a request which cannot be read, such as a body which is not valid JSON.
+func respondMalformed(w http.ResponseWriter, detail string) {
+	helper.RespondProblem(w, http.StatusBadRequest, model.ErrorCodeMalformedRequest, detail)
+}
+
+// respondForbidden responds 403 Forbidden to a request for a record the user cannot access.
+func respondForbidden(w http.ResponseWriter) {
+	helper.RespondProblem(w, http.StatusForbidden, model.ErrorCodeForbidden, "you cannot access this record")
+}
+
+// respondMissingClaims responds 500 to a request which reached a handler without the claims of its token.
+func respondMissingClaims(w http.ResponseWriter) {
+	helper.RespondProblem(w, http.StatusInternalServerError, model.ErrorCodeInternal, "missing user claims")
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+)
+
+// setETag sets the ETag header to the version of an entity, for the client to send it back in If-Match.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatch reads from the If-Match header the version of the entity a write expects. It answers
+// 428 Precondition Required when the header is missing, or 400 Bad Request when it is not a version,
+// and returns false.
+func ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.Header.Get("If-Match")
+	if value == "" {
+		helper.RespondProblem(w, http.StatusPreconditionRequired, model.ErrorCodeVersionRequired, "If-Match header required")
+		return 0, false
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(value, "W/"))
+	if err != nil {
+		respondMalformed(w, "invalid If-Match header")
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		respondMalformed(w, "invalid If-Match header")
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// IdempotencyMiddleware makes the POST requests of authenticated users carrying an Idempotency-Key header
+// safe to retry. The response to the first request made with a key is stored, and replayed to its retries,
+// as long as they have the same method, URL and body; a different request answers 422 Unprocessable Entity,
+// and a retry made while the first request is still processed answers 409 Conflict. Failed requests,
+// answered with a server error, can be retried, and responses marked no-store, such as tokens, are not
+// kept. The keys of every user are kept apart.
+func IdempotencyMiddleware(ir repository.IdempotencyRepository) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			claims, ok := helper.RequestClaims(r)
+			if r.Method != http.MethodPost || key == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				respondMalformed(w, err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			key = claims.UserID.Hex() + ":" + key
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+			hash.Write(body)
+			fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+			previous, err := ir.Reserve(key, fingerprint)
+			switch {
+			case err != nil:
+				respondError(w, err)
+			case previous == nil:
+				serveIdempotent(w, r, next, ir, key)
+			case previous.Fingerprint != fingerprint:
+				helper.RespondProblem(w, http.StatusUnprocessableEntity, model.ErrorCodeIdempotencyKeyReused, "Idempotency-Key already used for another request")
+			case previous.Status == model.IdempotencyStatusInFlight:
+				helper.RespondProblem(w, http.StatusConflict, model.ErrorCodeRequestInProgress, "a request with this Idempotency-Key is still in progress")
+			default:
+				w.Header().Set("Content-Type", previous.ContentType)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(previous.StatusCode)
+				w.Write(previous.Body)
+			}
+		})
+	}
+}
+
+// serveIdempotent serves the first request made with the key, and stores its response.
+func serveIdempotent(w http.ResponseWriter, r *http.Request, next http.Handler, ir repository.IdempotencyRepository, key string) {
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	stored := false
+	defer func() {
+		if !stored {
+			if err := ir.Release(key); err != nil {
+				log.Printf("Releasing the idempotency key %s failed: %v\n", key, err)
+			}
+		}
+	}()
+
+	next.ServeHTTP(recorder, r)
+	if recorder.statusCode >= http.StatusInternalServerError || strings.Contains(recorder.Header().Get("Cache-Control"), "no-store") {
+		return
+	}
+	err := ir.Complete(key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	if err != nil {
+		log.Printf("Storing the response of the idempotency key %s failed: %v\n", key, err)
+		return
+	}
+	stored = true
+}
+
+// responseRecorder writes a response while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// invoiceRequest is the body of the requests registering a supplier invoice. Without a total,
+// the total of its lines is taken.
+type invoiceRequest struct {
+	TenantID   primitive.ObjectID   `json:"tenant"`
+	Number     string               `json:"number" validate:"required"`
+	Date       time.Time            `json:"date"`
+	SupplierID primitive.ObjectID   `json:"supplier" validate:"required"`
+	Lines      []invoiceLinePayload `json:"lines" validate:"required"`
+	Total      float64              `json:"total" validate:"min=0"`
+}
+
+// invoiceLinePayload is a line of an invoice, billing units of a purchase, in requests and responses.
+type invoiceLinePayload struct {
+	PurchaseID primitive.ObjectID `json:"purchase" validate:"required"`
+	Quantity   int                `json:"quantity" validate:"gt=0"`
+	Amount     float64            `json:"amount" validate:"min=0"`
+}
+
+func (req *invoiceRequest) toModel() *model.Invoice {
+	var lines []model.InvoiceLine
+	for _, line := range req.Lines {
+		lines = append(lines, model.InvoiceLine(line))
+	}
+	return &model.Invoice{
+		TenantID:   req.TenantID,
+		Number:     req.Number,
+		Date:       req.Date,
+		SupplierID: req.SupplierID,
+		Lines:      lines,
+		Total:      req.Total,
+	}
+}
+
+// invoiceResponse is an invoice as the API returns it, with the outcome of its matching.
+type invoiceResponse struct {
+	ID            primitive.ObjectID   `json:"id"`
+	TenantID      *primitive.ObjectID  `json:"tenant,omitempty"`
+	Number        string               `json:"number"`
+	Date          time.Time            `json:"date"`
+	SupplierID    primitive.ObjectID   `json:"supplier"`
+	Lines         []invoiceLinePayload `json:"lines"`
+	Total         float64              `json:"total"`
+	Status        string               `json:"status"`
+	Discrepancies []string             `json:"discrepancies"`
+	ResolvedBy    *primitive.ObjectID  `json:"resolvedBy,omitempty"`
+	DeletedAt     *time.Time           `json:"deletedAt,omitempty"`
+	DeletedBy     *primitive.ObjectID  `json:"deletedBy,omitempty"`
+	Version       int                  `json:"version"`
+}
+
+func newInvoiceResponse(invoice *model.Invoice) invoiceResponse {
+	lines := make([]invoiceLinePayload, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		lines = append(lines, invoiceLinePayload(line))
+	}
+	return invoiceResponse{
+		ID:            invoice.ID,
+		TenantID:      optionalID(invoice.TenantID),
+		Number:        invoice.Number,
+		Date:          invoice.Date,
+		SupplierID:    invoice.SupplierID,
+		Lines:         lines,
+		Total:         invoice.Total,
+		Status:        invoice.Status,
+		Discrepancies: invoice.Discrepancies,
+		ResolvedBy:    optionalID(invoice.ResolvedBy),
+		DeletedAt:     invoice.DeletedAt,
+		DeletedBy:     optionalID(invoice.DeletedBy),
+		Version:       invoice.Version,
+	}
+}
+
+func newInvoiceResponses(invoices []model.Invoice) []invoiceResponse {
+	responses := make([]invoiceResponse, 0, len(invoices))
+	for i := range invoices {
+		responses = append(responses, newInvoiceResponse(&invoices[i]))
+	}
+	return responses
+}
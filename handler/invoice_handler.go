@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// InvoiceHandler handles HTTP requests related to supplier invoices.
+type InvoiceHandler struct {
+	ir repository.InvoiceRepository
+}
+
+// NewInvoiceHandler creates a new instance of InvoiceHandler.
+func NewInvoiceHandler(ir repository.InvoiceRepository) *InvoiceHandler {
+	return &InvoiceHandler{ir: ir}
+}
+
+// CreateInvoiceHandler handles requests to record a supplier invoice.
+func (h *InvoiceHandler) CreateInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	var request invoiceRequest
+	if !decodeRequest(w, r, "invoice", &request) {
+		return
+	}
+	invoice := request.toModel()
+
+	err := h.ir.WithScope(requestScope(r)).CreateInvoice(invoice)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newInvoiceResponse(invoice))
+}
+
+// GetInvoiceHandler handles requests to retrieve an invoice by ID.
+func (h *InvoiceHandler) GetInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	invoiceID := params["id"]
+
+	invoice, err := h.ir.WithScope(requestScope(r)).GetInvoiceByID(invoiceID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newInvoiceResponse(invoice))
+}
+
+// ListInvoicesHandler handles requests to retrieve the invoices, optionally filtered by status.
+func (h *InvoiceHandler) ListInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	invoices, err := h.ir.WithScope(requestScope(r)).ListByStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newInvoiceResponses(invoices))
+}
+
+// ListExceptionsHandler handles requests to retrieve the exception queue.
+func (h *InvoiceHandler) ListExceptionsHandler(w http.ResponseWriter, r *http.Request) {
+	invoices, err := h.ir.WithScope(requestScope(r)).ListByStatus(model.InvoiceStatusException)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newInvoiceResponses(invoices))
+}
+
+// ResolveInvoiceHandler handles requests to resolve an invoice of the exception queue.
+func (h *InvoiceHandler) ResolveInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	invoiceID := params["id"]
+
+	var resolution struct {
+		Status string `json:"status" validate:"required,oneof=payable rejected"`
+	}
+	if !decodeRequest(w, r, "invoice resolution", &resolution) {
+		return
+	}
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+
+	err := h.ir.WithScope(requestScope(r)).ResolveInvoice(invoiceID, resolution.Status, claims.UserID.Hex())
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Invoice resolved successfully"})
+}
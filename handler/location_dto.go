@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// locationRequest is the body of the requests creating or replacing a location.
+type locationRequest struct {
+	TenantID        primitive.ObjectID `json:"tenant"`
+	Name            string             `json:"name" validate:"required"`
+	Price           float64            `json:"price" validate:"min=0"`
+	SupplierID      primitive.ObjectID `json:"supplier"`
+	TrackStock      bool               `json:"trackStock"`
+	Stock           int                `json:"stock" validate:"min=0"`
+	ReorderPoint    int                `json:"reorderPoint" validate:"min=0"`
+	ReorderQuantity int                `json:"reorderQuantity" validate:"min=0"`
+}
+
+// newLocationRequest returns the request replacing a location by itself, for merge patches.
+func newLocationRequest(location *model.Location) *locationRequest {
+	return &locationRequest{
+		TenantID:        location.TenantID,
+		Name:            location.Name,
+		Price:           location.Price,
+		SupplierID:      location.SupplierID,
+		TrackStock:      location.TrackStock,
+		Stock:           location.Stock,
+		ReorderPoint:    location.ReorderPoint,
+		ReorderQuantity: location.ReorderQuantity,
+	}
+}
+
+func (req *locationRequest) toModel() *model.Location {
+	return &model.Location{
+		TenantID:        req.TenantID,
+		Name:            req.Name,
+		Price:           req.Price,
+		SupplierID:      req.SupplierID,
+		TrackStock:      req.TrackStock,
+		Stock:           req.Stock,
+		ReorderPoint:    req.ReorderPoint,
+		ReorderQuantity: req.ReorderQuantity,
+	}
+}
+
+// locationOperationRequest is an operation of a bulk location request.
+type locationOperationRequest struct {
+	Action   string           `json:"action" validate:"required,oneof=create update delete"`
+	ID       string           `json:"id,omitempty"`
+	Version  int              `json:"version"`
+	Location *locationRequest `json:"location,omitempty"`
+}
+
+func newLocationOperations(requests []locationOperationRequest) []model.LocationOperation {
+	operations := make([]model.LocationOperation, 0, len(requests))
+	for _, req := range requests {
+		operation := model.LocationOperation{Action: req.Action, ID: req.ID, Version: req.Version}
+		if req.Location != nil {
+			operation.Location = req.Location.toModel()
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// locationResponse is a location as the API returns it.
+type locationResponse struct {
+	ID              primitive.ObjectID  `json:"id"`
+	TenantID        *primitive.ObjectID `json:"tenant,omitempty"`
+	Name            string              `json:"name"`
+	Price           float64             `json:"price"`
+	SupplierID      primitive.ObjectID  `json:"supplier"`
+	SupplierName    string              `json:"supplierName"`
+	TrackStock      bool                `json:"trackStock"`
+	Stock           int                 `json:"stock"`
+	ReorderPoint    int                 `json:"reorderPoint"`
+	ReorderQuantity int                 `json:"reorderQuantity"`
+	DeletedAt       *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy       *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version         int                 `json:"version"`
+}
+
+func newLocationResponse(location *model.Location) locationResponse {
+	return locationResponse{
+		ID:              location.ID,
+		TenantID:        optionalID(location.TenantID),
+		Name:            location.Name,
+		Price:           location.Price,
+		SupplierID:      location.SupplierID,
+		SupplierName:    location.SupplierName,
+		TrackStock:      location.TrackStock,
+		Stock:           location.Stock,
+		ReorderPoint:    location.ReorderPoint,
+		ReorderQuantity: location.ReorderQuantity,
+		DeletedAt:       location.DeletedAt,
+		DeletedBy:       optionalID(location.DeletedBy),
+		Version:         location.Version,
+	}
+}
+
+func newLocationResponses(locations []model.Location) []locationResponse {
+	responses := make([]locationResponse, 0, len(locations))
+	for i := range locations {
+		responses = append(responses, newLocationResponse(&locations[i]))
+	}
+	return responses
+}
+
+// priceChangeResponse is a price change requested by a supplier as the API returns it.
+type priceChangeResponse struct {
+	ID             primitive.ObjectID  `json:"id"`
+	TenantID       *primitive.ObjectID `json:"tenant,omitempty"`
+	LocationID     primitive.ObjectID  `json:"location"`
+	SupplierID     primitive.ObjectID  `json:"supplier"`
+	CurrentPrice   float64             `json:"currentPrice"`
+	RequestedPrice float64             `json:"requestedPrice"`
+	Status         string              `json:"status"`
+	RequestedBy    primitive.ObjectID  `json:"requestedBy"`
+	RequestedAt    time.Time           `json:"requestedAt"`
+	ReviewedBy     *primitive.ObjectID `json:"reviewedBy,omitempty"`
+	ReviewedAt     *time.Time          `json:"reviewedAt,omitempty"`
+	Version        int                 `json:"version"`
+}
+
+func newPriceChangeResponses(priceChanges []model.PriceChange) []priceChangeResponse {
+	responses := make([]priceChangeResponse, 0, len(priceChanges))
+	for _, priceChange := range priceChanges {
+		responses = append(responses, priceChangeResponse{
+			ID:             priceChange.ID,
+			TenantID:       optionalID(priceChange.TenantID),
+			LocationID:     priceChange.LocationID,
+			SupplierID:     priceChange.SupplierID,
+			CurrentPrice:   priceChange.CurrentPrice,
+			RequestedPrice: priceChange.RequestedPrice,
+			Status:         priceChange.Status,
+			RequestedBy:    priceChange.RequestedBy,
+			RequestedAt:    priceChange.RequestedAt,
+			ReviewedBy:     optionalID(priceChange.ReviewedBy),
+			ReviewedAt:     optionalTime(priceChange.ReviewedAt),
+			Version:        priceChange.Version,
+		})
+	}
+	return responses
+}
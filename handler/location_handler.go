@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -11,25 +11,28 @@ import (
 )
 
 type LocationHandler struct {
-	lr repository.LocationRepository
+	lr  repository.LocationRepository
+	uow repository.UnitOfWork
 }
 
-func NewLocationHandler(r repository.LocationRepository) *LocationHandler {
-	return &LocationHandler{lr: r}
+func NewLocationHandler(r repository.LocationRepository, uow repository.UnitOfWork) *LocationHandler {
+	return &LocationHandler{lr: r, uow: uow}
 }
 
+// errForbidden stops a unit of work when the requesting user turns out not to have access to a record.
+var errForbidden = errors.New("Forbidden")
+
 // CreateLocationHandler handles requests to create a new location.
 func (h *LocationHandler) CreateLocationHandler(w http.ResponseWriter, r *http.Request) {
-	var newLocation model.Location
-	err := json.NewDecoder(r.Body).Decode(&newLocation)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request locationRequest
+	if !decodeRequest(w, r, "location", &request) {
 		return
 	}
+	newLocation := request.toModel()
 
-	err = h.lr.CreateLocation(&newLocation)
+	err := h.lr.WithScope(requestScope(r)).CreateLocation(newLocation)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
@@ -41,71 +44,306 @@ func (h *LocationHandler) GetLocationByIDHandler(w http.ResponseWriter, r *http.
 	params := mux.Vars(r)
 	locationID := params["id"]
 
-	location, err := h.lr.GetLocationByID(locationID)
+	at, asOf, err := parseAsOf(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMalformed(w, "invalid asOf parameter")
+		return
+	}
+	var location *model.Location
+	if asOf {
+		location, err = h.lr.WithScope(requestScope(r)).GetLocationAsOf(locationID, at)
+	} else {
+		location, err = h.lr.WithScope(requestScope(r)).GetLocationByID(locationID)
+	}
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !canAccessSupplier(r, location.SupplierID) {
+		respondForbidden(w)
 		return
 	}
 
-	helper.RespondJSON(w, location)
+	setETag(w, location.Version)
+	helper.RespondJSON(w, newLocationResponse(location))
 }
 
 // UpdateLocationHandler handles requests to update an existing location.
+// Supplier users can only update the locations of their supplier, and the prices
+// they submit wait for an admin approval instead of being applied. The stock and reorder settings
+// belong to the buyer, so supplier users cannot change them. The price change request and the
+// update are applied together or not at all.
 func (h *LocationHandler) UpdateLocationHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	locationID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	var updatedLocation model.Location
-	err := json.NewDecoder(r.Body).Decode(&updatedLocation)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request locationRequest
+	if !decodeRequest(w, r, "location", &request) {
 		return
 	}
+	updatedLocation := request.toModel()
 
-	err = h.lr.UpdateLocation(locationID, &updatedLocation)
+	var message string
+	requestedPrice := updatedLocation.Price
+	err := h.uow.Do(requestScope(r), func(scope repository.Scope) error {
+		lr := h.lr.WithScope(scope)
+		message = "Location updated successfully"
+		updatedLocation.Price = requestedPrice
+		if _, scoped := supplierScope(r); scoped {
+			location, err := lr.GetLocationByID(locationID)
+			if err != nil {
+				return err
+			}
+			if !canAccessSupplier(r, location.SupplierID) {
+				return errForbidden
+			}
+			if location.Version != version {
+				return repository.ErrVersionMismatch
+			}
+			updatedLocation.SupplierID = location.SupplierID
+			updatedLocation.TrackStock = location.TrackStock
+			updatedLocation.Stock = location.Stock
+			updatedLocation.ReorderPoint = location.ReorderPoint
+			updatedLocation.ReorderQuantity = location.ReorderQuantity
+
+			if requestedPrice != location.Price {
+				claims := r.Context().Value("userClaims").(*model.Claims)
+				if _, err := lr.RequestPriceChange(locationID, requestedPrice, claims.UserID.Hex()); err != nil {
+					return err
+				}
+				updatedLocation.Price = location.Price
+				message = "Location updated successfully, the price change awaits approval"
+			}
+		}
+		return lr.UpdateLocation(locationID, updatedLocation, version)
+	})
+	if errors.Is(err, errForbidden) {
+		respondForbidden(w)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, map[string]string{"message": "Location updated successfully"})
+	setETag(w, version+1)
+	helper.RespondJSON(w, map[string]string{"message": message})
+}
+
+// PatchLocationHandler handles requests to partially update an existing location with a JSON merge patch.
+func (h *LocationHandler) PatchLocationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID := params["id"]
+
+	location, err := h.lr.WithScope(requestScope(r)).GetLocationByID(locationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !mergePatch(w, r, newLocationRequest(location)) {
+		return
+	}
+	h.UpdateLocationHandler(w, r)
 }
 
 // DeleteLocationHandler handles requests to delete a location by ID.
 func (h *LocationHandler) DeleteLocationHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	locationID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := h.lr.DeleteLocation(locationID)
+	err := h.lr.WithScope(requestScope(r)).DeleteLocation(locationID, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	helper.RespondJSON(w, map[string]string{"message": "Location deleted successfully"})
 }
 
+// RestoreLocationHandler handles requests to restore a deleted location by ID.
+func (h *LocationHandler) RestoreLocationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID := params["id"]
+
+	err := h.lr.WithScope(requestScope(r)).RestoreLocation(locationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Location restored successfully"})
+}
+
 // GetAllLocationsHandler handles requests to retrieve all unique locations.
 func (h *LocationHandler) ListAllLocationsHandler(w http.ResponseWriter, r *http.Request) {
-	locations, err := h.lr.ListAll()
+	var locations []model.Location
+	var err error
+	if supplierID, scoped := supplierScope(r); scoped {
+		locations, err = h.lr.WithScope(requestScope(r)).ListBySupplier(supplierID.Hex())
+	} else {
+		locations, err = h.lr.WithScope(requestScope(r)).ListAll()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, locations)
+	helper.RespondJSON(w, newLocationResponses(locations))
 }
 
 // ListBySupplierHandler handles requests to retrieve a list of all locations for a specific supplier.
 func (h *LocationHandler) ListBySupplierHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	supplierID := params["id"]
+	if ownSupplierID, scoped := supplierScope(r); scoped && ownSupplierID.Hex() != supplierID {
+		respondForbidden(w)
+		return
+	}
+
+	locations, err := h.lr.WithScope(requestScope(r)).ListBySupplier(supplierID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newLocationResponses(locations))
+}
+
+// ReorderReportHandler handles requests to list the locations about to run out of stock.
+func (h *LocationHandler) ReorderReportHandler(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.lr.WithScope(requestScope(r)).ListBelowReorderPoint()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newLocationResponses(locations))
+}
+
+// ListPriceChangesHandler handles requests to list the price changes submitted by suppliers, optionally filtered by status.
+func (h *LocationHandler) ListPriceChangesHandler(w http.ResponseWriter, r *http.Request) {
+	priceChanges, err := h.lr.WithScope(requestScope(r)).ListPriceChanges(r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newPriceChangeResponses(priceChanges))
+}
+
+// ApprovePriceChangeHandler handles requests to approve a price change and apply it to its location.
+func (h *LocationHandler) ApprovePriceChangeHandler(w http.ResponseWriter, r *http.Request) {
+	h.reviewPriceChange(w, r, true)
+}
+
+// RejectPriceChangeHandler handles requests to reject a price change.
+func (h *LocationHandler) RejectPriceChangeHandler(w http.ResponseWriter, r *http.Request) {
+	h.reviewPriceChange(w, r, false)
+}
+
+func (h *LocationHandler) reviewPriceChange(w http.ResponseWriter, r *http.Request, approved bool) {
+	params := mux.Vars(r)
+	priceChangeID := params["id"]
+
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+
+	err := h.lr.WithScope(requestScope(r)).ReviewPriceChange(priceChangeID, approved, claims.UserID.Hex())
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Price change reviewed successfully"})
+}
+
+// ListLocationVersionsHandler handles requests to retrieve the versions of a location.
+func (h *LocationHandler) ListLocationVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID := params["id"]
+
+	versions, err := h.lr.WithScope(requestScope(r)).ListLocationVersions(locationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newVersionResponses(versions))
+}
+
+// RevertLocationHandler handles requests to bring a location back to one of its versions.
+func (h *LocationHandler) RevertLocationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	number, err := versionNumber(r)
+	if err != nil {
+		respondMalformed(w, "invalid version number")
+		return
+	}
+
+	err = h.lr.WithScope(requestScope(r)).RevertLocation(locationID, number, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, map[string]string{"message": "Location reverted successfully"})
+}
+
+// BulkLocationsHandler handles requests to create, update and delete many locations at once.
+// With atomic set, either all the operations are applied or none.
+func (h *LocationHandler) BulkLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Atomic     bool                       `json:"atomic"`
+		Operations []locationOperationRequest `json:"operations"`
+	}
+	if !decodeRequest(w, r, "bulk operation", &request) {
+		return
+	}
+
+	results, err := h.lr.WithScope(requestScope(r)).BulkWrite(newLocationOperations(request.Operations), request.Atomic)
+
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, results)
+}
+
+// AdjustPricesHandler handles requests to change the prices of all the locations of a supplier by a percentage.
+func (h *LocationHandler) AdjustPricesHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	var adjustment struct {
+		Percentage float64 `json:"percentage" validate:"gt=-100"`
+	}
+	if !decodeRequest(w, r, "price adjustment", &adjustment) {
+		return
+	}
 
-	locations, err := h.lr.ListBySupplier(supplierID)
+	adjusted, err := h.lr.WithScope(requestScope(r)).AdjustPrices(supplierID, adjustment.Percentage)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, locations)
+	helper.RespondJSON(w, map[string]interface{}{"message": "Location prices adjusted successfully", "locations": adjusted})
 }
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// organisationRequest is the body of the requests creating or replacing an organisation.
+type organisationRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// newOrganisationRequest returns the request replacing an organisation by itself, for merge patches.
+func newOrganisationRequest(organisation *model.Organisation) *organisationRequest {
+	return &organisationRequest{Name: organisation.Name}
+}
+
+func (req *organisationRequest) toModel() *model.Organisation {
+	return &model.Organisation{Name: req.Name}
+}
+
+// organisationResponse is an organisation as the API returns it.
+type organisationResponse struct {
+	ID        primitive.ObjectID  `json:"id"`
+	Name      string              `json:"name"`
+	DeletedAt *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version   int                 `json:"version"`
+}
+
+func newOrganisationResponse(organisation *model.Organisation) organisationResponse {
+	return organisationResponse{
+		ID:        organisation.ID,
+		Name:      organisation.Name,
+		DeletedAt: organisation.DeletedAt,
+		DeletedBy: optionalID(organisation.DeletedBy),
+		Version:   organisation.Version,
+	}
+}
+
+func newOrganisationResponses(organisations []model.Organisation) []organisationResponse {
+	responses := make([]organisationResponse, 0, len(organisations))
+	for i := range organisations {
+		responses = append(responses, newOrganisationResponse(&organisations[i]))
+	}
+	return responses
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// OrganisationHandler handles HTTP requests related to organisations.
+type OrganisationHandler struct {
+	or repository.OrganisationRepository
+}
+
+// NewOrganisationHandler creates a new instance of OrganisationHandler.
+func NewOrganisationHandler(or repository.OrganisationRepository) *OrganisationHandler {
+	return &OrganisationHandler{or: or}
+}
+
+// CreateOrganisationHandler handles requests to create a new organisation.
+func (h *OrganisationHandler) CreateOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	var request organisationRequest
+	if !decodeRequest(w, r, "organisation", &request) {
+		return
+	}
+	organisation := request.toModel()
+
+	err := h.or.WithScope(requestScope(r)).CreateOrganisation(organisation)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newOrganisationResponse(organisation))
+}
+
+// GetOrganisationHandler handles requests to retrieve an organisation by ID.
+func (h *OrganisationHandler) GetOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	organisationID := params["id"]
+
+	organisation, err := h.or.WithScope(requestScope(r)).GetOrganisationByID(organisationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, organisation.Version)
+	helper.RespondJSON(w, newOrganisationResponse(organisation))
+}
+
+// UpdateOrganisationHandler handles requests to update an existing organisation.
+func (h *OrganisationHandler) UpdateOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	organisationID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var request organisationRequest
+	if !decodeRequest(w, r, "organisation", &request) {
+		return
+	}
+	updatedOrganisation := request.toModel()
+
+	err := h.or.WithScope(requestScope(r)).UpdateOrganisation(organisationID, updatedOrganisation, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, newOrganisationResponse(updatedOrganisation))
+}
+
+// PatchOrganisationHandler handles requests to partially update an existing organisation with a JSON merge patch.
+func (h *OrganisationHandler) PatchOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	organisationID := params["id"]
+
+	organisation, err := h.or.WithScope(requestScope(r)).GetOrganisationByID(organisationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !mergePatch(w, r, newOrganisationRequest(organisation)) {
+		return
+	}
+	h.UpdateOrganisationHandler(w, r)
+}
+
+// DeleteOrganisationHandler handles requests to delete an organisation by ID.
+func (h *OrganisationHandler) DeleteOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	organisationID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.or.WithScope(requestScope(r)).DeleteOrganisation(organisationID, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Organisation deleted successfully"})
+}
+
+// RestoreOrganisationHandler handles requests to restore a deleted organisation by ID.
+func (h *OrganisationHandler) RestoreOrganisationHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	organisationID := params["id"]
+
+	err := h.or.WithScope(requestScope(r)).RestoreOrganisation(organisationID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Organisation restored successfully"})
+}
+
+// ListOrganisationsHandler handles requests to retrieve a list of all organisations.
+func (h *OrganisationHandler) ListOrganisationsHandler(w http.ResponseWriter, r *http.Request) {
+	organisations, err := h.or.WithScope(requestScope(r)).ListAll()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newOrganisationResponses(organisations))
+}
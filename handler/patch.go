@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/helper"
+)
+
+// mergePatch applies the JSON merge patch of the request body to the current state of an entity,
+// and replaces the body with the result. The PATCH handlers then hand the request to the PUT handlers,
+// so that the merged entity is checked and validated as a whole. It answers 400 Bad Request
+// when the patch is invalid, and returns false.
+func mergePatch(w http.ResponseWriter, r *http.Request, current interface{}) bool {
+	document, err := json.Marshal(current)
+	if err != nil {
+		respondError(w, err)
+		return false
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondMalformed(w, err.Error())
+		return false
+	}
+	merged, err := helper.MergePatch(document, patch)
+	if err != nil {
+		respondMalformed(w, err.Error())
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(merged))
+	return true
+}
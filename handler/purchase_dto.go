@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// purchaseRequest is the body of the requests creating or replacing a purchase. Its prices, deliveries
+// and returns are computed by the API. New purchases belong to the requesting user.
+type purchaseRequest struct {
+	Quantity     int                `json:"quantity" validate:"gt=0"`
+	Date         time.Time          `json:"date"`
+	ExpectedDate time.Time          `json:"expectedDate"`
+	Fees         float64            `json:"fees" validate:"min=0,max=1"`
+	Status       string             `json:"status" validate:"oneof=draft ordered"`
+	UserID       primitive.ObjectID `json:"user"`
+	LocationID   primitive.ObjectID `json:"location" validate:"required"`
+}
+
+// newPurchaseRequest returns the request replacing a purchase by itself, for merge patches.
+func newPurchaseRequest(purchase *model.Purchase) *purchaseRequest {
+	return &purchaseRequest{
+		Quantity:     purchase.Quantity,
+		Date:         purchase.Date,
+		ExpectedDate: purchase.ExpectedDate,
+		Fees:         purchase.Fees,
+		Status:       purchase.Status,
+		UserID:       purchase.UserID,
+		LocationID:   purchase.LocationID,
+	}
+}
+
+func (req *purchaseRequest) toModel() *model.Purchase {
+	return &model.Purchase{
+		Quantity:     req.Quantity,
+		Date:         req.Date,
+		ExpectedDate: req.ExpectedDate,
+		Fees:         req.Fees,
+		Status:       req.Status,
+		UserID:       req.UserID,
+		LocationID:   req.LocationID,
+	}
+}
+
+// purchaseOperationRequest is an operation of a bulk purchase request.
+type purchaseOperationRequest struct {
+	Action   string           `json:"action" validate:"required,oneof=create update delete"`
+	ID       string           `json:"id,omitempty"`
+	Version  int              `json:"version"`
+	Purchase *purchaseRequest `json:"purchase,omitempty"`
+}
+
+func newPurchaseOperations(requests []purchaseOperationRequest) []model.PurchaseOperation {
+	operations := make([]model.PurchaseOperation, 0, len(requests))
+	for _, req := range requests {
+		operation := model.PurchaseOperation{Action: req.Action, ID: req.ID, Version: req.Version}
+		if req.Purchase != nil {
+			operation.Purchase = req.Purchase.toModel()
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// purchaseResponse is a purchase as the API returns it. Its buyer is left out for supplier users.
+type purchaseResponse struct {
+	ID                  primitive.ObjectID  `json:"id"`
+	TenantID            *primitive.ObjectID `json:"tenant,omitempty"`
+	Quantity            int                 `json:"quantity"`
+	Date                time.Time           `json:"date"`
+	ExpectedDate        time.Time           `json:"expectedDate"`
+	Fees                float64             `json:"fees"`
+	UnitPrice           float64             `json:"unitPrice"`
+	TotalPrice          float64             `json:"totalPrice"`
+	Status              string              `json:"status"`
+	ReceivedQuantity    int                 `json:"receivedQuantity"`
+	OutstandingQuantity int                 `json:"outstandingQuantity"`
+	DeliveryStatus      string              `json:"deliveryStatus"`
+	DeliveryClosed      bool                `json:"deliveryClosed"`
+	ReturnedQuantity    int                 `json:"returnedQuantity"`
+	CreditedAmount      float64             `json:"creditedAmount"`
+	UserID              *primitive.ObjectID `json:"user,omitempty"`
+	UserName            string              `json:"userName,omitempty"`
+	LocationID          primitive.ObjectID  `json:"location"`
+	LocationName        string              `json:"locationName"`
+	SupplierID          primitive.ObjectID  `json:"supplier"`
+	SupplierName        string              `json:"supplierName"`
+	ContractID          *primitive.ObjectID `json:"contract,omitempty"`
+	OffContract         bool                `json:"offContract"`
+	RFQID               *primitive.ObjectID `json:"rfq,omitempty"`
+	QuotedPrice         float64             `json:"quotedPrice,omitempty"`
+	DeletedAt           *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy           *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version             int                 `json:"version"`
+}
+
+func newPurchaseResponse(purchase *model.Purchase) purchaseResponse {
+	return purchaseResponse{
+		ID:                  purchase.ID,
+		TenantID:            optionalID(purchase.TenantID),
+		Quantity:            purchase.Quantity,
+		Date:                purchase.Date,
+		ExpectedDate:        purchase.ExpectedDate,
+		Fees:                purchase.Fees,
+		UnitPrice:           purchase.UnitPrice,
+		TotalPrice:          purchase.TotalPrice,
+		Status:              purchase.Status,
+		ReceivedQuantity:    purchase.ReceivedQuantity,
+		OutstandingQuantity: purchase.OutstandingQuantity,
+		DeliveryStatus:      purchase.DeliveryStatus,
+		DeliveryClosed:      purchase.DeliveryClosed,
+		ReturnedQuantity:    purchase.ReturnedQuantity,
+		CreditedAmount:      purchase.CreditedAmount,
+		UserID:              optionalID(purchase.UserID),
+		UserName:            purchase.UserName,
+		LocationID:          purchase.LocationID,
+		LocationName:        purchase.LocationName,
+		SupplierID:          purchase.SupplierID,
+		SupplierName:        purchase.SupplierName,
+		ContractID:          optionalID(purchase.ContractID),
+		OffContract:         purchase.OffContract,
+		RFQID:               optionalID(purchase.RFQID),
+		QuotedPrice:         purchase.QuotedPrice,
+		DeletedAt:           purchase.DeletedAt,
+		DeletedBy:           optionalID(purchase.DeletedBy),
+		Version:             purchase.Version,
+	}
+}
+
+func newPurchaseResponses(purchases []model.Purchase) []purchaseResponse {
+	responses := make([]purchaseResponse, 0, len(purchases))
+	for i := range purchases {
+		responses = append(responses, newPurchaseResponse(&purchases[i]))
+	}
+	return responses
+}
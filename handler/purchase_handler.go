@@ -1,13 +1,13 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/sandlayth/supplier-api/helper"
 	"github.com/sandlayth/supplier-api/model"
 	"github.com/sandlayth/supplier-api/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // PurchaseHandler handles HTTP requests related to purchases.
@@ -22,25 +22,24 @@ func NewPurchaseHandler(pr repository.PurchaseRepository) *PurchaseHandler {
 
 // CreatePurchaseHandler handles requests to create a new purchase.
 func (h *PurchaseHandler) CreatePurchaseHandler(w http.ResponseWriter, r *http.Request) {
-	var purchase model.Purchase
-	err := json.NewDecoder(r.Body).Decode(&purchase)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request purchaseRequest
+	if !decodeRequest(w, r, "purchase", &request) {
 		return
 	}
+	purchase := request.toModel()
 	claims, ok := r.Context().Value("userClaims").(*model.Claims)
 	if !ok {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMissingClaims(w)
 		return
 	}
 	purchase.UserID = claims.UserID
-	err = h.pr.CreatePurchase(&purchase)
+	err := h.pr.WithScope(requestScope(r)).CreatePurchase(purchase)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, purchase)
+	helper.RespondJSON(w, newPurchaseResponse(purchase))
 }
 
 // GetPurchaseHandler handles requests to retrieve a purchase by ID.
@@ -48,82 +47,136 @@ func (h *PurchaseHandler) GetPurchaseHandler(w http.ResponseWriter, r *http.Requ
 	params := mux.Vars(r)
 	purchaseID := params["id"]
 
-	purchase, err := h.pr.GetPurchaseByID(purchaseID)
+	at, asOf, err := parseAsOf(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMalformed(w, "invalid asOf parameter")
 		return
 	}
-
-	if purchase == nil {
-		http.NotFound(w, r)
+	var purchase *model.Purchase
+	if asOf {
+		purchase, err = h.pr.WithScope(requestScope(r)).GetPurchaseAsOf(purchaseID, at)
+	} else {
+		purchase, err = h.pr.WithScope(requestScope(r)).GetPurchaseByID(purchaseID)
+	}
+	if err != nil {
+		respondError(w, err)
 		return
 	}
+
 	claims, ok := r.Context().Value("userClaims").(*model.Claims)
 	if !ok {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMissingClaims(w)
+		return
+	}
+	// Managers see their own purchases and supplier users the purchases placed with their supplier
+	if (claims.Role == "manager" && claims.UserID != purchase.UserID) || !canAccessSupplier(r, purchase.SupplierID) {
+		respondForbidden(w)
 		return
 	}
-	if claims.UserID.Hex() != purchase.ID.Hex() {
-		helper.RespondJSON(w, nil)
+	// Supplier users do not see the buyer, as in the purchases they list
+	if claims.Role == "supplier" {
+		purchase.UserID = primitive.NilObjectID
+		purchase.UserName = ""
 	}
-	helper.RespondJSON(w, purchase)
+	setETag(w, purchase.Version)
+	helper.RespondJSON(w, newPurchaseResponse(purchase))
 }
 
 // UpdatePurchaseHandler handles requests to update a purchase by ID.
 func (h *PurchaseHandler) UpdatePurchaseHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	purchaseID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	var updatedPurchase model.Purchase
-	err := json.NewDecoder(r.Body).Decode(&updatedPurchase)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request purchaseRequest
+	if !decodeRequest(w, r, "purchase", &request) {
 		return
 	}
+	updatedPurchase := request.toModel()
 
-	err = h.pr.UpdatePurchase(purchaseID, &updatedPurchase)
+	err := h.pr.WithScope(requestScope(r)).UpdatePurchase(purchaseID, updatedPurchase, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, updatedPurchase)
+	setETag(w, version+1)
+	helper.RespondJSON(w, newPurchaseResponse(updatedPurchase))
+}
+
+// PatchPurchaseHandler handles requests to partially update an existing purchase with a JSON merge patch.
+func (h *PurchaseHandler) PatchPurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	purchase, err := h.pr.WithScope(requestScope(r)).GetPurchaseByID(purchaseID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !mergePatch(w, r, newPurchaseRequest(purchase)) {
+		return
+	}
+	h.UpdatePurchaseHandler(w, r)
 }
 
 // DeletePurchaseHandler handles requests to delete a purchase by ID.
 func (h *PurchaseHandler) DeletePurchaseHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	purchaseID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := h.pr.DeletePurchase(purchaseID)
+	err := h.pr.WithScope(requestScope(r)).DeletePurchase(purchaseID, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	helper.RespondJSON(w, map[string]string{"message": "Purchase deleted successfully"})
 }
 
+// RestorePurchaseHandler handles requests to restore a deleted purchase by ID.
+func (h *PurchaseHandler) RestorePurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	err := h.pr.WithScope(requestScope(r)).RestorePurchase(purchaseID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Purchase restored successfully"})
+}
+
 // ListAllPurchasesHandler handles requests to retrieve a list of all purchases.
 func (h *PurchaseHandler) ListAllPurchasesHandler(w http.ResponseWriter, r *http.Request) {
 	var purchases []model.Purchase
 	var err error
 	claims, ok := r.Context().Value("userClaims").(*model.Claims)
 	if !ok {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondMissingClaims(w)
 		return
 	}
 	if claims.Role == "admin" {
-		purchases, err = h.pr.ListAll()
+		purchases, err = h.pr.WithScope(requestScope(r)).ListAll()
+	} else if claims.Role == "supplier" {
+		purchases, err = h.pr.WithScope(requestScope(r)).ListPurchasesBySupplier(claims.SupplierID.Hex())
 	} else {
-		purchases, err = h.pr.ListPurchasesByUser(claims.UserID.Hex())
+		purchases, err = h.pr.WithScope(requestScope(r)).ListPurchasesByUser(claims.UserID.Hex())
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, purchases)
+	helper.RespondJSON(w, newPurchaseResponses(purchases))
 }
 
 // ListPurchasesByUserHandler handles requests to retrieve a list of purchases for a specific user.
@@ -131,11 +184,81 @@ func (h *PurchaseHandler) ListPurchasesByUserHandler(w http.ResponseWriter, r *h
 	params := mux.Vars(r)
 	userID := params["userID"]
 
-	purchases, err := h.pr.ListPurchasesByUser(userID)
+	purchases, err := h.pr.WithScope(requestScope(r)).ListPurchasesByUser(userID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newPurchaseResponses(purchases))
+}
+
+// ListPurchaseVersionsHandler handles requests to retrieve the versions of a purchase.
+func (h *PurchaseHandler) ListPurchaseVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	versions, err := h.pr.WithScope(requestScope(r)).ListPurchaseVersions(purchaseID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newVersionResponses(versions))
+}
+
+// RevertPurchaseHandler handles requests to bring a purchase back to one of its versions.
+func (h *PurchaseHandler) RevertPurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	number, err := versionNumber(r)
+	if err != nil {
+		respondMalformed(w, "invalid version number")
+		return
+	}
+
+	err = h.pr.WithScope(requestScope(r)).RevertPurchase(purchaseID, number, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, map[string]string{"message": "Purchase reverted successfully"})
+}
+
+// BulkPurchasesHandler handles requests to create, update and delete many purchases at once.
+// The purchases created are attributed to the requesting user. With atomic set, either all
+// the operations are applied or none.
+func (h *PurchaseHandler) BulkPurchasesHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Atomic     bool                       `json:"atomic"`
+		Operations []purchaseOperationRequest `json:"operations"`
+	}
+	if !decodeRequest(w, r, "bulk operation", &request) {
+		return
+	}
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+	for _, operation := range request.Operations {
+		if operation.Action == model.BulkActionCreate && operation.Purchase != nil {
+			operation.Purchase.UserID = claims.UserID
+		}
+	}
+
+	results, err := h.pr.WithScope(requestScope(r)).BulkWrite(newPurchaseOperations(request.Operations), request.Atomic)
+
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, purchases)
+	helper.RespondJSON(w, results)
 }
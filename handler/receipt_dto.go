@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// receiptRequest is the body of the requests recording goods received for a purchase. Final closes
+// the delivery of the purchase, whatever is still outstanding.
+type receiptRequest struct {
+	Quantity int       `json:"quantity" validate:"gt=0"`
+	Date     time.Time `json:"date"`
+	Final    bool      `json:"final"`
+}
+
+func (req *receiptRequest) toModel() *model.Receipt {
+	return &model.Receipt{Quantity: req.Quantity, Date: req.Date, Final: req.Final}
+}
+
+// receiptResponse is a receipt as the API returns it.
+type receiptResponse struct {
+	ID            primitive.ObjectID  `json:"id"`
+	TenantID      *primitive.ObjectID `json:"tenant,omitempty"`
+	PurchaseID    primitive.ObjectID  `json:"purchase"`
+	Quantity      int                 `json:"quantity"`
+	Date          time.Time           `json:"date"`
+	Final         bool                `json:"final"`
+	ReceiverID    primitive.ObjectID  `json:"receiver"`
+	ReceiverName  string              `json:"receiverName"`
+	OverDelivery  bool                `json:"overDelivery"`
+	UnderDelivery bool                `json:"underDelivery"`
+	DeletedAt     *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy     *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version       int                 `json:"version"`
+}
+
+func newReceiptResponse(receipt *model.Receipt) receiptResponse {
+	return receiptResponse{
+		ID:            receipt.ID,
+		TenantID:      optionalID(receipt.TenantID),
+		PurchaseID:    receipt.PurchaseID,
+		Quantity:      receipt.Quantity,
+		Date:          receipt.Date,
+		Final:         receipt.Final,
+		ReceiverID:    receipt.ReceiverID,
+		ReceiverName:  receipt.ReceiverName,
+		OverDelivery:  receipt.OverDelivery,
+		UnderDelivery: receipt.UnderDelivery,
+		DeletedAt:     receipt.DeletedAt,
+		DeletedBy:     optionalID(receipt.DeletedBy),
+		Version:       receipt.Version,
+	}
+}
+
+func newReceiptResponses(receipts []model.Receipt) []receiptResponse {
+	responses := make([]receiptResponse, 0, len(receipts))
+	for i := range receipts {
+		responses = append(responses, newReceiptResponse(&receipts[i]))
+	}
+	return responses
+}
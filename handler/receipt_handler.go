@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// ReceiptHandler handles HTTP requests related to goods receipts.
+type ReceiptHandler struct {
+	rr repository.ReceiptRepository
+}
+
+// NewReceiptHandler creates a new instance of ReceiptHandler.
+func NewReceiptHandler(rr repository.ReceiptRepository) *ReceiptHandler {
+	return &ReceiptHandler{rr: rr}
+}
+
+// CreateReceiptHandler handles requests to record goods received for a purchase.
+func (h *ReceiptHandler) CreateReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	var request receiptRequest
+	if !decodeRequest(w, r, "receipt", &request) {
+		return
+	}
+	receipt := request.toModel()
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+	receipt.ReceiverID = claims.UserID
+
+	err := h.rr.WithScope(requestScope(r)).CreateReceipt(purchaseID, receipt)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newReceiptResponse(receipt))
+}
+
+// ListReceiptsHandler handles requests to retrieve the receipts of a purchase.
+func (h *ReceiptHandler) ListReceiptsHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	receipts, err := h.rr.WithScope(requestScope(r)).ListByPurchase(purchaseID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newReceiptResponses(receipts))
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// decodeRequest reads the JSON body of a request into v, and checks it against the validate tags of its type.
+// It answers 400 Bad Request when the body is not valid JSON or has fields v does not have, and 422 Unprocessable
+// Entity with every invalid field when it breaks a rule, and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, entity string, v interface{}) bool {
+	if err := helper.DecodeJSON(r.Body, v); err != nil {
+		respondMalformed(w, err.Error())
+		return false
+	}
+	if fields := helper.Validate(v); len(fields) > 0 {
+		respondError(w, &repository.ValidationError{Entity: entity, Fields: fields})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// optionalID returns the ID for an optional field of a response, nil when it is not set, for the field
+// to be left out instead of answered as a zero ID.
+func optionalID(id primitive.ObjectID) *primitive.ObjectID {
+	if id.IsZero() {
+		return nil
+	}
+	return &id
+}
+
+// optionalTime returns the time for an optional field of a response, nil when it is not set.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// returnRequest is the body of the requests returning units of a purchase to its supplier.
+type returnRequest struct {
+	Quantity int       `json:"quantity" validate:"gt=0"`
+	Reason   string    `json:"reason" validate:"required"`
+	Date     time.Time `json:"date"`
+}
+
+func (req *returnRequest) toModel() *model.PurchaseReturn {
+	return &model.PurchaseReturn{Quantity: req.Quantity, Reason: req.Reason, Date: req.Date}
+}
+
+// returnResponse is a purchase return as the API returns it, with the amount credited for it.
+type returnResponse struct {
+	ID           primitive.ObjectID  `json:"id"`
+	TenantID     *primitive.ObjectID `json:"tenant,omitempty"`
+	PurchaseID   primitive.ObjectID  `json:"purchase"`
+	Quantity     int                 `json:"quantity"`
+	Reason       string              `json:"reason"`
+	CreditAmount float64             `json:"creditAmount"`
+	Date         time.Time           `json:"date"`
+	UserID       primitive.ObjectID  `json:"user"`
+	UserName     string              `json:"userName"`
+	DeletedAt    *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy    *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version      int                 `json:"version"`
+}
+
+func newReturnResponse(purchaseReturn *model.PurchaseReturn) returnResponse {
+	return returnResponse{
+		ID:           purchaseReturn.ID,
+		TenantID:     optionalID(purchaseReturn.TenantID),
+		PurchaseID:   purchaseReturn.PurchaseID,
+		Quantity:     purchaseReturn.Quantity,
+		Reason:       purchaseReturn.Reason,
+		CreditAmount: purchaseReturn.CreditAmount,
+		Date:         purchaseReturn.Date,
+		UserID:       purchaseReturn.UserID,
+		UserName:     purchaseReturn.UserName,
+		DeletedAt:    purchaseReturn.DeletedAt,
+		DeletedBy:    optionalID(purchaseReturn.DeletedBy),
+		Version:      purchaseReturn.Version,
+	}
+}
+
+func newReturnResponses(returns []model.PurchaseReturn) []returnResponse {
+	responses := make([]returnResponse, 0, len(returns))
+	for i := range returns {
+		responses = append(responses, newReturnResponse(&returns[i]))
+	}
+	return responses
+}
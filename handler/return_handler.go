@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// ReturnHandler handles HTTP requests related to purchase returns.
+type ReturnHandler struct {
+	rr repository.ReturnRepository
+}
+
+// NewReturnHandler creates a new instance of ReturnHandler.
+func NewReturnHandler(rr repository.ReturnRepository) *ReturnHandler {
+	return &ReturnHandler{rr: rr}
+}
+
+// CreateReturnHandler handles requests to return goods from a purchase.
+func (h *ReturnHandler) CreateReturnHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	var request returnRequest
+	if !decodeRequest(w, r, "return", &request) {
+		return
+	}
+	purchaseReturn := request.toModel()
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+	purchaseReturn.UserID = claims.UserID
+
+	err := h.rr.WithScope(requestScope(r)).CreateReturn(purchaseID, purchaseReturn)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newReturnResponse(purchaseReturn))
+}
+
+// ListReturnsHandler handles requests to retrieve the returns of a purchase.
+func (h *ReturnHandler) ListReturnsHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	purchaseID := params["id"]
+
+	returns, err := h.rr.WithScope(requestScope(r)).ListByPurchase(purchaseID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newReturnResponses(returns))
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rfqRequest is the body of the requests opening an RFQ. Every invited supplier gets a token
+// to answer it with.
+type rfqRequest struct {
+	TenantID    primitive.ObjectID     `json:"tenant"`
+	Title       string                 `json:"title" validate:"required"`
+	Items       []rfqItemPayload       `json:"items" validate:"required"`
+	Invitations []rfqInvitationRequest `json:"invitations" validate:"required"`
+	Deadline    time.Time              `json:"deadline" validate:"required"`
+}
+
+// rfqItemPayload is an item an RFQ asks prices for, in requests and responses.
+type rfqItemPayload struct {
+	Description string `json:"description" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"gt=0"`
+}
+
+// rfqInvitationRequest invites a supplier to answer an RFQ.
+type rfqInvitationRequest struct {
+	SupplierID primitive.ObjectID `json:"supplier" validate:"required"`
+}
+
+func (req *rfqRequest) toModel() *model.RFQ {
+	var items []model.RFQItem
+	for _, item := range req.Items {
+		items = append(items, model.RFQItem(item))
+	}
+	var invitations []model.RFQInvitation
+	for _, invitation := range req.Invitations {
+		invitations = append(invitations, model.RFQInvitation{SupplierID: invitation.SupplierID})
+	}
+	return &model.RFQ{
+		TenantID:    req.TenantID,
+		Title:       req.Title,
+		Items:       items,
+		Invitations: invitations,
+		Deadline:    req.Deadline,
+	}
+}
+
+// quoteRequest is the body of the requests submitting a quote. The supplier is only given by admins
+// entering a quote for a supplier; the suppliers answering through their token are known by it.
+type quoteRequest struct {
+	SupplierID   primitive.ObjectID `json:"supplier"`
+	Lines        []quoteLinePayload `json:"lines" validate:"required"`
+	LeadTimeDays int                `json:"leadTimeDays" validate:"min=0"`
+}
+
+// quoteLinePayload prices an item of an RFQ, by its index, at a location of the supplier, in requests and responses.
+type quoteLinePayload struct {
+	Item       int                `json:"item" validate:"min=0"`
+	LocationID primitive.ObjectID `json:"location" validate:"required"`
+	UnitPrice  float64            `json:"unitPrice" validate:"min=0"`
+}
+
+func (req *quoteRequest) toModel() *model.Quote {
+	var lines []model.QuoteLine
+	for _, line := range req.Lines {
+		lines = append(lines, model.QuoteLine(line))
+	}
+	return &model.Quote{SupplierID: req.SupplierID, Lines: lines, LeadTimeDays: req.LeadTimeDays}
+}
+
+// rfqResponse is an RFQ as the API returns it, with its quotes.
+type rfqResponse struct {
+	ID             primitive.ObjectID      `json:"id"`
+	TenantID       *primitive.ObjectID     `json:"tenant,omitempty"`
+	Title          string                  `json:"title"`
+	Items          []rfqItemPayload        `json:"items"`
+	Invitations    []rfqInvitationResponse `json:"invitations"`
+	Deadline       time.Time               `json:"deadline"`
+	Status         string                  `json:"status"`
+	Quotes         []quoteResponse         `json:"quotes"`
+	AwardedQuoteID *primitive.ObjectID     `json:"awardedQuote,omitempty"`
+	PurchaseIDs    []primitive.ObjectID    `json:"purchases"`
+	DeletedAt      *time.Time              `json:"deletedAt,omitempty"`
+	DeletedBy      *primitive.ObjectID     `json:"deletedBy,omitempty"`
+	Version        int                     `json:"version"`
+}
+
+// rfqInvitationResponse is the invitation of a supplier, with the token to hand over to it.
+type rfqInvitationResponse struct {
+	SupplierID primitive.ObjectID `json:"supplier"`
+	Token      string             `json:"token"`
+}
+
+// quoteResponse is a quote as the API returns it, with its total.
+type quoteResponse struct {
+	ID           primitive.ObjectID `json:"id"`
+	SupplierID   primitive.ObjectID `json:"supplier"`
+	Lines        []quoteLinePayload `json:"lines"`
+	LeadTimeDays int                `json:"leadTimeDays"`
+	Total        float64            `json:"total"`
+	SubmittedAt  time.Time          `json:"submittedAt"`
+}
+
+func newRFQResponse(rfq *model.RFQ) rfqResponse {
+	items := make([]rfqItemPayload, 0, len(rfq.Items))
+	for _, item := range rfq.Items {
+		items = append(items, rfqItemPayload(item))
+	}
+	invitations := make([]rfqInvitationResponse, 0, len(rfq.Invitations))
+	for _, invitation := range rfq.Invitations {
+		invitations = append(invitations, rfqInvitationResponse(invitation))
+	}
+	quotes := make([]quoteResponse, 0, len(rfq.Quotes))
+	for i := range rfq.Quotes {
+		quotes = append(quotes, newQuoteResponse(&rfq.Quotes[i]))
+	}
+	return rfqResponse{
+		ID:             rfq.ID,
+		TenantID:       optionalID(rfq.TenantID),
+		Title:          rfq.Title,
+		Items:          items,
+		Invitations:    invitations,
+		Deadline:       rfq.Deadline,
+		Status:         rfq.Status,
+		Quotes:         quotes,
+		AwardedQuoteID: optionalID(rfq.AwardedQuoteID),
+		PurchaseIDs:    rfq.PurchaseIDs,
+		DeletedAt:      rfq.DeletedAt,
+		DeletedBy:      optionalID(rfq.DeletedBy),
+		Version:        rfq.Version,
+	}
+}
+
+func newRFQResponses(rfqs []model.RFQ) []rfqResponse {
+	responses := make([]rfqResponse, 0, len(rfqs))
+	for i := range rfqs {
+		responses = append(responses, newRFQResponse(&rfqs[i]))
+	}
+	return responses
+}
+
+func newQuoteResponse(quote *model.Quote) quoteResponse {
+	lines := make([]quoteLinePayload, 0, len(quote.Lines))
+	for _, line := range quote.Lines {
+		lines = append(lines, quoteLinePayload(line))
+	}
+	return quoteResponse{
+		ID:           quote.ID,
+		SupplierID:   quote.SupplierID,
+		Lines:        lines,
+		LeadTimeDays: quote.LeadTimeDays,
+		Total:        quote.Total,
+		SubmittedAt:  quote.SubmittedAt,
+	}
+}
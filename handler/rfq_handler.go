@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+)
+
+// RFQHandler handles HTTP requests related to requests for quote.
+type RFQHandler struct {
+	rr repository.RFQRepository
+}
+
+// NewRFQHandler creates a new instance of RFQHandler.
+func NewRFQHandler(rr repository.RFQRepository) *RFQHandler {
+	return &RFQHandler{rr: rr}
+}
+
+// CreateRFQHandler handles requests to create a new RFQ.
+func (h *RFQHandler) CreateRFQHandler(w http.ResponseWriter, r *http.Request) {
+	var request rfqRequest
+	if !decodeRequest(w, r, "rfq", &request) {
+		return
+	}
+	rfq := request.toModel()
+
+	err := h.rr.WithScope(requestScope(r)).CreateRFQ(rfq)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newRFQResponse(rfq))
+}
+
+// GetRFQHandler handles requests to retrieve an RFQ by ID.
+func (h *RFQHandler) GetRFQHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	rfqID := params["id"]
+
+	rfq, err := h.rr.WithScope(requestScope(r)).GetRFQByID(rfqID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newRFQResponse(rfq))
+}
+
+// ListRFQsHandler handles requests to retrieve a list of all RFQs.
+func (h *RFQHandler) ListRFQsHandler(w http.ResponseWriter, r *http.Request) {
+	rfqs, err := h.rr.WithScope(requestScope(r)).ListAll()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newRFQResponses(rfqs))
+}
+
+// SubmitQuoteHandler handles requests from admins to enter the quote of an invited supplier.
+func (h *RFQHandler) SubmitQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	rfqID := params["id"]
+
+	var request quoteRequest
+	if !decodeRequest(w, r, "quote", &request) {
+		return
+	}
+	quote := request.toModel()
+
+	err := h.rr.WithScope(requestScope(r)).SubmitQuote(rfqID, quote)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newQuoteResponse(quote))
+}
+
+// SubmitQuoteByTokenHandler handles requests from suppliers answering an RFQ through their tokenised link.
+func (h *RFQHandler) SubmitQuoteByTokenHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	token := params["token"]
+
+	var request quoteRequest
+	if !decodeRequest(w, r, "quote", &request) {
+		return
+	}
+	quote := request.toModel()
+
+	err := h.rr.WithScope(requestScope(r)).SubmitQuoteByToken(token, quote)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newQuoteResponse(quote))
+}
+
+// CompareQuotesHandler handles requests to compare the quotes of an RFQ side by side.
+func (h *RFQHandler) CompareQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	rfqID := params["id"]
+
+	comparisons, err := h.rr.WithScope(requestScope(r)).CompareQuotes(rfqID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, comparisons)
+}
+
+// AwardQuoteHandler handles requests to award an RFQ to a quote, converting it into purchases.
+func (h *RFQHandler) AwardQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	rfqID := params["id"]
+
+	var award struct {
+		QuoteID string `json:"quote" validate:"required"`
+	}
+	if !decodeRequest(w, r, "award", &award) {
+		return
+	}
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+
+	rfq, err := h.rr.WithScope(requestScope(r)).AwardQuote(rfqID, award.QuoteID, claims.UserID.Hex())
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newRFQResponse(rfq))
+}
@@ -8,12 +8,21 @@ import (
 func AddUserRoutes(r *mux.Router, handler *UserHandler) {
 	r.HandleFunc("/users/login", handler.LoginHandler).Methods("POST")
 	r.HandleFunc("/users/{id}/renew-token", handler.RenewTokenHandler).Methods("POST")
+	// Self-service routes, open to every role and registered first for "me" not to be taken for a user ID
+	profileRouter := r.PathPrefix("/users/me").Subrouter()
+	profileRouter.Use(helper.ManagerOrSupplierAuthorizationMiddleware)
+	profileRouter.HandleFunc("", handler.GetProfileHandler).Methods("GET")
+	profileRouter.HandleFunc("", handler.PatchProfileHandler).Methods("PATCH")
+	profileRouter.HandleFunc("/password", handler.ChangePasswordHandler).Methods("POST")
+
 	// Admin Routes
 	adminRouter := r.PathPrefix("/users").Subrouter()
 	adminRouter.Use(helper.AdminAuthorizationMiddleware)
 	adminRouter.HandleFunc("", handler.CreateUserHandler).Methods("POST")
 	adminRouter.HandleFunc("/{id}", handler.UpdateUserHandler).Methods("PUT")
+	adminRouter.HandleFunc("/{id}", handler.PatchUserHandler).Methods("PATCH")
 	adminRouter.HandleFunc("/{id}", handler.DeleteUserHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/restore", handler.RestoreUserHandler).Methods("POST")
 	adminRouter.HandleFunc("", handler.ListUsersHandler).Methods("GET")
 	adminRouter.HandleFunc("/{id}", handler.GetUserHandler).Methods("GET")
 	//	r.HandleFunc("/logout", handler.LogoutHandler).Methods("POST")
@@ -22,41 +31,162 @@ func AddUserRoutes(r *mux.Router, handler *UserHandler) {
 func AddLocationRoutes(r *mux.Router, handler *LocationHandler) {
 	adminRouter := r.PathPrefix("/locations").Subrouter()
 	adminRouter.Use(helper.AdminAuthorizationMiddleware)
-	adminRouter.HandleFunc("/{id}", handler.UpdateLocationHandler).Methods("PUT")
+	adminRouter.HandleFunc("/price-changes", handler.ListPriceChangesHandler).Methods("GET")
+	adminRouter.HandleFunc("/price-changes/{id}/approve", handler.ApprovePriceChangeHandler).Methods("POST")
+	adminRouter.HandleFunc("/price-changes/{id}/reject", handler.RejectPriceChangeHandler).Methods("POST")
+	adminRouter.HandleFunc("/bulk", handler.BulkLocationsHandler).Methods("POST")
+	adminRouter.HandleFunc("/supplier/{id}/price-adjustment", handler.AdjustPricesHandler).Methods("POST")
 	adminRouter.HandleFunc("/{id}", handler.DeleteLocationHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/restore", handler.RestoreLocationHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/versions", handler.ListLocationVersionsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/versions/{number}/revert", handler.RevertLocationHandler).Methods("POST")
 	adminRouter.HandleFunc("", handler.CreateLocationHandler).Methods("POST")
 
+	supplierRouter := r.PathPrefix("/locations").Subrouter()
+	supplierRouter.Use(helper.SupplierAuthorizationMiddleware)
+	supplierRouter.HandleFunc("/{id}", handler.UpdateLocationHandler).Methods("PUT")
+	supplierRouter.HandleFunc("/{id}", handler.PatchLocationHandler).Methods("PATCH")
+
 	managerRouter := r.PathPrefix("/locations").Subrouter()
 	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
-	managerRouter.HandleFunc("/{id}", handler.GetLocationByIDHandler).Methods("GET")
-	managerRouter.HandleFunc("", handler.ListAllLocationsHandler).Methods("GET")
-	managerRouter.HandleFunc("/supplier/{id}", handler.ListBySupplierHandler).Methods("GET")
+	managerRouter.HandleFunc("/reorder-report", handler.ReorderReportHandler).Methods("GET")
+
+	portalRouter := r.PathPrefix("/locations").Subrouter()
+	portalRouter.Use(helper.ManagerOrSupplierAuthorizationMiddleware)
+	portalRouter.HandleFunc("/{id}", handler.GetLocationByIDHandler).Methods("GET")
+	portalRouter.HandleFunc("", handler.ListAllLocationsHandler).Methods("GET")
+	portalRouter.HandleFunc("/supplier/{id}", handler.ListBySupplierHandler).Methods("GET")
 }
 
 func AddSupplierRoutes(r *mux.Router, handler *SupplierHandler) {
 	adminRouter := r.PathPrefix("/suppliers").Subrouter()
 	adminRouter.Use(helper.AdminAuthorizationMiddleware)
 	adminRouter.HandleFunc("", handler.CreateSupplierHandler).Methods("POST")
-	adminRouter.HandleFunc("/{id}", handler.UpdateSupplierHandler).Methods("PUT")
 	adminRouter.HandleFunc("/{id}", handler.DeleteSupplierHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/restore", handler.RestoreSupplierHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/versions", handler.ListSupplierVersionsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/versions/{number}/revert", handler.RevertSupplierHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/status", handler.ChangeSupplierStatusHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/onboarding", handler.UpdateOnboardingHandler).Methods("PUT")
+
+	supplierRouter := r.PathPrefix("/suppliers").Subrouter()
+	supplierRouter.Use(helper.SupplierAuthorizationMiddleware)
+	supplierRouter.HandleFunc("/{id}", handler.UpdateSupplierHandler).Methods("PUT")
+	supplierRouter.HandleFunc("/{id}", handler.PatchSupplierHandler).Methods("PATCH")
 
 	managerRouter := r.PathPrefix("/suppliers").Subrouter()
 	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
-	managerRouter.HandleFunc("/{id}", handler.GetSupplierByIDHandler).Methods("GET")
-	managerRouter.HandleFunc("", handler.GetAllSuppliersHandler).Methods("GET")
+	managerRouter.HandleFunc("/report", handler.SupplierReportHandler).Methods("GET")
+
+	portalRouter := r.PathPrefix("/suppliers").Subrouter()
+	portalRouter.Use(helper.ManagerOrSupplierAuthorizationMiddleware)
+	portalRouter.HandleFunc("/{id}", handler.GetSupplierByIDHandler).Methods("GET")
+	portalRouter.HandleFunc("", handler.GetAllSuppliersHandler).Methods("GET")
 }
 
 // AddPurchaseRoutes adds purchase-related routes to the provided router.
 func AddPurchaseRoutes(r *mux.Router, handler *PurchaseHandler) {
 	adminRouter := r.PathPrefix("/purchases").Subrouter()
 	adminRouter.Use(helper.AdminAuthorizationMiddleware)
+	adminRouter.HandleFunc("/bulk", handler.BulkPurchasesHandler).Methods("POST")
 	adminRouter.HandleFunc("/{id}", handler.UpdatePurchaseHandler).Methods("PUT")
+	adminRouter.HandleFunc("/{id}", handler.PatchPurchaseHandler).Methods("PATCH")
 	adminRouter.HandleFunc("/{id}", handler.DeletePurchaseHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/restore", handler.RestorePurchaseHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/versions", handler.ListPurchaseVersionsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/versions/{number}/revert", handler.RevertPurchaseHandler).Methods("POST")
 	adminRouter.HandleFunc("/user/{userID}", handler.ListPurchasesByUserHandler).Methods("GET")
 
 	managerRouter := r.PathPrefix("/purchases").Subrouter()
 	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
 	managerRouter.HandleFunc("", handler.CreatePurchaseHandler).Methods("POST")
-	managerRouter.HandleFunc("/{id}", handler.GetPurchaseHandler).Methods("GET")
-	managerRouter.HandleFunc("", handler.ListAllPurchasesHandler).Methods("GET")
+
+	portalRouter := r.PathPrefix("/purchases").Subrouter()
+	portalRouter.Use(helper.ManagerOrSupplierAuthorizationMiddleware)
+	portalRouter.HandleFunc("/{id}", handler.GetPurchaseHandler).Methods("GET")
+	portalRouter.HandleFunc("", handler.ListAllPurchasesHandler).Methods("GET")
+}
+
+// AddReceiptRoutes adds the goods receiving routes to the provided router.
+func AddReceiptRoutes(r *mux.Router, handler *ReceiptHandler) {
+	managerRouter := r.PathPrefix("/purchases/{id}/receipts").Subrouter()
+	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
+	managerRouter.HandleFunc("", handler.CreateReceiptHandler).Methods("POST")
+	managerRouter.HandleFunc("", handler.ListReceiptsHandler).Methods("GET")
+}
+
+// AddReturnRoutes adds the purchase return routes to the provided router.
+func AddReturnRoutes(r *mux.Router, handler *ReturnHandler) {
+	managerRouter := r.PathPrefix("/purchases/{id}/returns").Subrouter()
+	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
+	managerRouter.HandleFunc("", handler.CreateReturnHandler).Methods("POST")
+	managerRouter.HandleFunc("", handler.ListReturnsHandler).Methods("GET")
+}
+
+// AddInvoiceRoutes adds the supplier invoice routes to the provided router.
+func AddInvoiceRoutes(r *mux.Router, handler *InvoiceHandler) {
+	adminRouter := r.PathPrefix("/invoices").Subrouter()
+	adminRouter.Use(helper.AdminAuthorizationMiddleware)
+	adminRouter.HandleFunc("", handler.CreateInvoiceHandler).Methods("POST")
+	adminRouter.HandleFunc("", handler.ListInvoicesHandler).Methods("GET")
+	adminRouter.HandleFunc("/exceptions", handler.ListExceptionsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", handler.GetInvoiceHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/resolve", handler.ResolveInvoiceHandler).Methods("POST")
+}
+
+// AddContractRoutes adds the supplier contract routes to the provided router.
+func AddContractRoutes(r *mux.Router, handler *ContractHandler) {
+	adminRouter := r.PathPrefix("/contracts").Subrouter()
+	adminRouter.Use(helper.AdminAuthorizationMiddleware)
+	adminRouter.HandleFunc("", handler.CreateContractHandler).Methods("POST")
+	adminRouter.HandleFunc("/expiring", handler.ExpiringContractsHandler).Methods("GET")
+	adminRouter.HandleFunc("/supplier/{id}", handler.ListBySupplierHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", handler.GetContractHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", handler.UpdateContractHandler).Methods("PUT")
+	adminRouter.HandleFunc("/{id}", handler.PatchContractHandler).Methods("PATCH")
+	adminRouter.HandleFunc("/{id}", handler.DeleteContractHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/{id}/restore", handler.RestoreContractHandler).Methods("POST")
+}
+
+// AddScorecardRoutes adds the supplier scorecard routes to the provided router.
+func AddScorecardRoutes(r *mux.Router, handler *ScorecardHandler) {
+	managerRouter := r.PathPrefix("/suppliers/{id}/scorecard").Subrouter()
+	managerRouter.Use(helper.ManagerAuthorizationMiddleware)
+	managerRouter.HandleFunc("", handler.GetScorecardHandler).Methods("GET")
+}
+
+// AddRFQRoutes adds the request for quote routes to the provided router.
+// Suppliers answer through their tokenised link, without an account.
+func AddRFQRoutes(r *mux.Router, handler *RFQHandler) {
+	r.HandleFunc("/rfqs/quotes/{token}", handler.SubmitQuoteByTokenHandler).Methods("POST")
+
+	adminRouter := r.PathPrefix("/rfqs").Subrouter()
+	adminRouter.Use(helper.AdminAuthorizationMiddleware)
+	adminRouter.HandleFunc("", handler.CreateRFQHandler).Methods("POST")
+	adminRouter.HandleFunc("", handler.ListRFQsHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}", handler.GetRFQHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/quotes", handler.SubmitQuoteHandler).Methods("POST")
+	adminRouter.HandleFunc("/{id}/comparison", handler.CompareQuotesHandler).Methods("GET")
+	adminRouter.HandleFunc("/{id}/award", handler.AwardQuoteHandler).Methods("POST")
+}
+
+// AddOrganisationRoutes adds the organisation management routes to the provided router.
+// Organisations are managed across tenants, by super-admins only.
+func AddOrganisationRoutes(r *mux.Router, handler *OrganisationHandler) {
+	superAdminRouter := r.PathPrefix("/organisations").Subrouter()
+	superAdminRouter.Use(helper.SuperAdminAuthorizationMiddleware)
+	superAdminRouter.HandleFunc("", handler.CreateOrganisationHandler).Methods("POST")
+	superAdminRouter.HandleFunc("", handler.ListOrganisationsHandler).Methods("GET")
+	superAdminRouter.HandleFunc("/{id}", handler.GetOrganisationHandler).Methods("GET")
+	superAdminRouter.HandleFunc("/{id}", handler.UpdateOrganisationHandler).Methods("PUT")
+	superAdminRouter.HandleFunc("/{id}", handler.PatchOrganisationHandler).Methods("PATCH")
+	superAdminRouter.HandleFunc("/{id}", handler.DeleteOrganisationHandler).Methods("DELETE")
+	superAdminRouter.HandleFunc("/{id}/restore", handler.RestoreOrganisationHandler).Methods("POST")
+}
+
+// AddAuditRoutes adds the audit log routes to the provided router.
+func AddAuditRoutes(r *mux.Router, handler *AuditHandler) {
+	adminRouter := r.PathPrefix("/audit").Subrouter()
+	adminRouter.Use(helper.AdminAuthorizationMiddleware)
+	adminRouter.HandleFunc("", handler.ListAuditHandler).Methods("GET")
 }
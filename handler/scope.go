@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// supplierScope returns the supplier a supplier user is bound to.
+// scoped is false for the other roles, which are not restricted to a supplier.
+func supplierScope(r *http.Request) (supplierID primitive.ObjectID, scoped bool) {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok || claims.Role != "supplier" {
+		return primitive.NilObjectID, false
+	}
+	return claims.SupplierID, true
+}
+
+// canAccessSupplier reports whether the request may see or change the given supplier's data.
+func canAccessSupplier(r *http.Request, supplierID primitive.ObjectID) bool {
+	ownSupplierID, scoped := supplierScope(r)
+	return !scoped || (!ownSupplierID.IsZero() && ownSupplierID == supplierID)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScorecardHandler handles HTTP requests related to supplier scorecards.
+type ScorecardHandler struct {
+	sr repository.ScorecardRepository
+	// window is the period covered when no time window is requested
+	window time.Duration
+}
+
+// NewScorecardHandler creates a new instance of ScorecardHandler.
+func NewScorecardHandler(sr repository.ScorecardRepository, window time.Duration) *ScorecardHandler {
+	return &ScorecardHandler{sr: sr, window: window}
+}
+
+// GetScorecardHandler handles requests to retrieve the scorecard of a supplier.
+// With from and/or to query parameters (YYYY-MM-DD) the scorecard is computed for that window,
+// otherwise the one refreshed by the background job is returned.
+func (h *ScorecardHandler) GetScorecardHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+	query := r.URL.Query()
+
+	if query.Get("from") == "" && query.Get("to") == "" {
+		scorecard, err := h.sr.WithScope(requestScope(r)).GetScorecard(supplierID)
+		if err == nil {
+			helper.RespondJSON(w, scorecard)
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			respondError(w, err)
+			return
+		}
+	}
+
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondMalformed(w, "invalid to parameter")
+			return
+		}
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+	from := to.Add(-h.window)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondMalformed(w, "invalid from parameter")
+			return
+		}
+		from = parsed
+	}
+
+	scorecard, err := h.sr.WithScope(requestScope(r)).ComputeScorecard(supplierID, from, to)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, scorecard)
+}
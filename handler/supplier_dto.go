@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// supplierRequest is the body of the requests creating or replacing a supplier. Its status and
+// onboarding checklist change through their own endpoints.
+type supplierRequest struct {
+	TenantID        primitive.ObjectID `json:"tenant"`
+	Name            string             `json:"name" validate:"required"`
+	Phone           string             `json:"phone"`
+	Email           string             `json:"email" validate:"email"`
+	Contacts        []contactPayload   `json:"contacts"`
+	BillingAddress  addressPayload     `json:"billingAddress"`
+	ShippingAddress addressPayload     `json:"shippingAddress"`
+	TaxID           string             `json:"taxId"`
+	BankDetails     bankDetailsPayload `json:"bankDetails"`
+	PaymentTerms    string             `json:"paymentTerms"`
+}
+
+// contactPayload is a contact of a supplier, in requests and responses.
+type contactPayload struct {
+	Name  string `json:"name" validate:"required"`
+	Role  string `json:"role" validate:"required,oneof=sales billing support"`
+	Email string `json:"email" validate:"email"`
+	Phone string `json:"phone"`
+}
+
+// addressPayload is a postal address, in requests and responses.
+type addressPayload struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// bankDetailsPayload is the bank account of a supplier, in requests and responses.
+type bankDetailsPayload struct {
+	AccountHolder string `json:"accountHolder"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+}
+
+// newSupplierRequest returns the request replacing a supplier by itself, for merge patches.
+func newSupplierRequest(supplier *model.Supplier) *supplierRequest {
+	return &supplierRequest{
+		TenantID:        supplier.TenantID,
+		Name:            supplier.Name,
+		Phone:           supplier.Phone,
+		Email:           supplier.Email,
+		Contacts:        newContactPayloads(supplier.Contacts),
+		BillingAddress:  addressPayload(supplier.BillingAddress),
+		ShippingAddress: addressPayload(supplier.ShippingAddress),
+		TaxID:           supplier.TaxID,
+		BankDetails:     bankDetailsPayload(supplier.BankDetails),
+		PaymentTerms:    supplier.PaymentTerms,
+	}
+}
+
+func (req *supplierRequest) toModel() *model.Supplier {
+	var contacts []model.Contact
+	for _, contact := range req.Contacts {
+		contacts = append(contacts, model.Contact(contact))
+	}
+	return &model.Supplier{
+		TenantID:        req.TenantID,
+		Name:            req.Name,
+		Phone:           req.Phone,
+		Email:           req.Email,
+		Contacts:        contacts,
+		BillingAddress:  model.Address(req.BillingAddress),
+		ShippingAddress: model.Address(req.ShippingAddress),
+		TaxID:           req.TaxID,
+		BankDetails:     model.BankDetails(req.BankDetails),
+		PaymentTerms:    req.PaymentTerms,
+	}
+}
+
+// supplierContactRequest is the body of the requests of supplier users updating their own supplier. They only
+// change its contact details: its name, tax ID, bank details and payment terms are left for admins to change.
+type supplierContactRequest struct {
+	Phone           string           `json:"phone"`
+	Email           string           `json:"email" validate:"email"`
+	Contacts        []contactPayload `json:"contacts"`
+	ShippingAddress addressPayload   `json:"shippingAddress"`
+}
+
+// newSupplierContactRequest returns the contact request replacing a supplier by itself, for merge patches.
+func newSupplierContactRequest(supplier *model.Supplier) *supplierContactRequest {
+	return &supplierContactRequest{
+		Phone:           supplier.Phone,
+		Email:           supplier.Email,
+		Contacts:        newContactPayloads(supplier.Contacts),
+		ShippingAddress: addressPayload(supplier.ShippingAddress),
+	}
+}
+
+// toModel returns the supplier with its contact details replaced by the ones of the request.
+func (req *supplierContactRequest) toModel(supplier *model.Supplier) *model.Supplier {
+	updatedSupplier := newSupplierRequest(supplier).toModel()
+	updatedSupplier.Phone = req.Phone
+	updatedSupplier.Email = req.Email
+	updatedSupplier.Contacts = nil
+	for _, contact := range req.Contacts {
+		updatedSupplier.Contacts = append(updatedSupplier.Contacts, model.Contact(contact))
+	}
+	updatedSupplier.ShippingAddress = model.Address(req.ShippingAddress)
+	return updatedSupplier
+}
+
+func newContactPayloads(contacts []model.Contact) []contactPayload {
+	payloads := make([]contactPayload, 0, len(contacts))
+	for _, contact := range contacts {
+		payloads = append(payloads, contactPayload(contact))
+	}
+	return payloads
+}
+
+// supplierResponse is a supplier as the API returns it.
+type supplierResponse struct {
+	ID              primitive.ObjectID  `json:"id"`
+	TenantID        *primitive.ObjectID `json:"tenant,omitempty"`
+	Name            string              `json:"name"`
+	Phone           string              `json:"phone"`
+	Email           string              `json:"email"`
+	Contacts        []contactPayload    `json:"contacts"`
+	BillingAddress  addressPayload      `json:"billingAddress"`
+	ShippingAddress addressPayload      `json:"shippingAddress"`
+	TaxID           string              `json:"taxId"`
+	BankDetails     bankDetailsPayload  `json:"bankDetails"`
+	PaymentTerms    string              `json:"paymentTerms"`
+	Status          string              `json:"status"`
+	Onboarding      onboardingPayload   `json:"onboarding"`
+	DeletedAt       *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy       *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version         int                 `json:"version"`
+}
+
+// onboardingPayload is the onboarding checklist of a supplier, in requests and responses.
+type onboardingPayload struct {
+	DocumentsReceived bool `json:"documentsReceived"`
+	TaxIDVerified     bool `json:"taxIdVerified"`
+}
+
+func newSupplierResponse(supplier *model.Supplier) supplierResponse {
+	return supplierResponse{
+		ID:              supplier.ID,
+		TenantID:        optionalID(supplier.TenantID),
+		Name:            supplier.Name,
+		Phone:           supplier.Phone,
+		Email:           supplier.Email,
+		Contacts:        newContactPayloads(supplier.Contacts),
+		BillingAddress:  addressPayload(supplier.BillingAddress),
+		ShippingAddress: addressPayload(supplier.ShippingAddress),
+		TaxID:           supplier.TaxID,
+		BankDetails:     bankDetailsPayload(supplier.BankDetails),
+		PaymentTerms:    supplier.PaymentTerms,
+		Status:          supplier.Status,
+		Onboarding:      onboardingPayload(supplier.Onboarding),
+		DeletedAt:       supplier.DeletedAt,
+		DeletedBy:       optionalID(supplier.DeletedBy),
+		Version:         supplier.Version,
+	}
+}
+
+func newSupplierResponses(suppliers []model.Supplier) []supplierResponse {
+	responses := make([]supplierResponse, 0, len(suppliers))
+	for i := range suppliers {
+		responses = append(responses, newSupplierResponse(&suppliers[i]))
+	}
+	return responses
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -23,39 +22,64 @@ func NewSupplierHandler(r repository.SupplierRepository) *SupplierHandler {
 func (h *SupplierHandler) GetSupplierByIDHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	supplierID := params["id"]
+	if ownSupplierID, scoped := supplierScope(r); scoped && ownSupplierID.Hex() != supplierID {
+		respondForbidden(w)
+		return
+	}
 
-	supplier, err := h.sr.GetSupplierByID(supplierID)
+	at, asOf, err := parseAsOf(r)
+	if err != nil {
+		respondMalformed(w, "invalid asOf parameter")
+		return
+	}
+	var supplier *model.Supplier
+	if asOf {
+		supplier, err = h.sr.WithScope(requestScope(r)).GetSupplierAsOf(supplierID, at)
+	} else {
+		supplier, err = h.sr.WithScope(requestScope(r)).GetSupplierByID(supplierID)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, supplier)
+	setETag(w, supplier.Version)
+	helper.RespondJSON(w, newSupplierResponse(supplier))
 }
 
 // GetAllSuppliersHandler handles requests to retrieve all suppliers.
+// Supplier users only get their own supplier.
 func (h *SupplierHandler) GetAllSuppliersHandler(w http.ResponseWriter, r *http.Request) {
-	suppliers, err := h.sr.ListAll()
+	if supplierID, scoped := supplierScope(r); scoped {
+		supplier, err := h.sr.WithScope(requestScope(r)).GetSupplierByID(supplierID.Hex())
+		if err != nil {
+			respondError(w, err)
+			return
+		}
+		helper.RespondJSON(w, []supplierResponse{newSupplierResponse(supplier)})
+		return
+	}
+
+	suppliers, err := h.sr.WithScope(requestScope(r)).ListAll()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, suppliers)
+	helper.RespondJSON(w, newSupplierResponses(suppliers))
 }
 
 // CreateSupplierHandler handles requests to create a new supplier.
 func (h *SupplierHandler) CreateSupplierHandler(w http.ResponseWriter, r *http.Request) {
-	var newSupplier model.Supplier
-	err := json.NewDecoder(r.Body).Decode(&newSupplier)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request supplierRequest
+	if !decodeRequest(w, r, "supplier", &request) {
 		return
 	}
+	newSupplier := request.toModel()
 
-	err = h.sr.CreateSupplier(&newSupplier)
+	err := h.sr.WithScope(requestScope(r)).CreateSupplier(newSupplier)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
@@ -63,36 +87,191 @@ func (h *SupplierHandler) CreateSupplierHandler(w http.ResponseWriter, r *http.R
 }
 
 // UpdateSupplierHandler handles requests to update an existing supplier.
+// Supplier users can only update the contact details of their own supplier.
 func (h *SupplierHandler) UpdateSupplierHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	supplierID := params["id"]
-
-	var updatedSupplier model.Supplier
-	err := json.NewDecoder(r.Body).Decode(&updatedSupplier)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	version, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
+	var updatedSupplier *model.Supplier
+	if ownSupplierID, scoped := supplierScope(r); scoped {
+		if ownSupplierID.Hex() != supplierID {
+			respondForbidden(w)
+			return
+		}
+		var request supplierContactRequest
+		if !decodeRequest(w, r, "supplier", &request) {
+			return
+		}
+		supplier, err := h.sr.WithScope(requestScope(r)).GetSupplierByID(supplierID)
+		if err != nil {
+			respondError(w, err)
+			return
+		}
+		updatedSupplier = request.toModel(supplier)
+	} else {
+		var request supplierRequest
+		if !decodeRequest(w, r, "supplier", &request) {
+			return
+		}
+		updatedSupplier = request.toModel()
+	}
 
-	err = h.sr.UpdateSupplier(supplierID, &updatedSupplier)
+	err := h.sr.WithScope(requestScope(r)).UpdateSupplier(supplierID, updatedSupplier, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
+	setETag(w, version+1)
 	helper.RespondJSON(w, map[string]string{"message": "Supplier updated successfully"})
 }
 
+// PatchSupplierHandler handles requests to partially update an existing supplier with a JSON merge patch.
+func (h *SupplierHandler) PatchSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	supplier, err := h.sr.WithScope(requestScope(r)).GetSupplierByID(supplierID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	var request interface{} = newSupplierRequest(supplier)
+	if _, scoped := supplierScope(r); scoped {
+		request = newSupplierContactRequest(supplier)
+	}
+	if !mergePatch(w, r, request) {
+		return
+	}
+	h.UpdateSupplierHandler(w, r)
+}
+
 // DeleteSupplierHandler handles requests to delete a supplier by ID.
 func (h *SupplierHandler) DeleteSupplierHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	supplierID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := h.sr.DeleteSupplier(supplierID)
+	err := h.sr.WithScope(requestScope(r)).DeleteSupplier(supplierID, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	helper.RespondJSON(w, map[string]string{"message": "Supplier deleted successfully"})
 }
+
+// RestoreSupplierHandler handles requests to restore a deleted supplier by ID.
+func (h *SupplierHandler) RestoreSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	err := h.sr.WithScope(requestScope(r)).RestoreSupplier(supplierID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Supplier restored successfully"})
+}
+
+// SupplierReportHandler handles requests to retrieve the purchase report of every supplier.
+func (h *SupplierHandler) SupplierReportHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.sr.WithScope(requestScope(r)).Report()
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, reports)
+}
+
+// ChangeSupplierStatusHandler handles requests to move a supplier through its lifecycle.
+func (h *SupplierHandler) ChangeSupplierStatusHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	var transition struct {
+		Status string `json:"status" validate:"required,oneof=onboarding active suspended blocked"`
+	}
+	if !decodeRequest(w, r, "status change", &transition) {
+		return
+	}
+
+	err := h.sr.WithScope(requestScope(r)).ChangeStatus(supplierID, transition.Status)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "Supplier status updated successfully"})
+}
+
+// UpdateOnboardingHandler handles requests to update the onboarding checklist of a supplier.
+func (h *SupplierHandler) UpdateOnboardingHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var checklist onboardingPayload
+	if !decodeRequest(w, r, "onboarding checklist", &checklist) {
+		return
+	}
+
+	err := h.sr.WithScope(requestScope(r)).UpdateOnboarding(supplierID, model.OnboardingChecklist(checklist), version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, map[string]string{"message": "Supplier onboarding updated successfully"})
+}
+
+// ListSupplierVersionsHandler handles requests to retrieve the versions of a supplier.
+func (h *SupplierHandler) ListSupplierVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+
+	versions, err := h.sr.WithScope(requestScope(r)).ListSupplierVersions(supplierID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, newVersionResponses(versions))
+
+}
+
+// RevertSupplierHandler handles requests to bring a supplier back to one of its versions.
+func (h *SupplierHandler) RevertSupplierHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	supplierID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	number, err := versionNumber(r)
+	if err != nil {
+		respondMalformed(w, "invalid version number")
+		return
+	}
+
+	err = h.sr.WithScope(requestScope(r)).RevertSupplier(supplierID, number, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, map[string]string{"message": "Supplier reverted successfully"})
+}
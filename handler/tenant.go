@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"github.com/sandlayth/supplier-api/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// requestScope returns the repository scope of the request: the organisation of the user,
+// or every organisation for super-admins which did not select one with the X-Tenant-ID header.
+// Requests without claims (login, token renewal, quotes submitted by token) are not restricted,
+// since the user is not known yet. The scope also carries what the audit log records of the request.
+func requestScope(r *http.Request) repository.Scope {
+	scope := repository.AllTenantsScope
+	if claims, ok := r.Context().Value("userClaims").(*model.Claims); ok {
+		if claims.Role != "superadmin" || !claims.TenantID.IsZero() {
+			scope = repository.Scope{TenantID: claims.TenantID}
+		}
+		scope.ActorID = claims.UserID
+	}
+	// Admins can list the deleted records along with the others
+	if r.Method == http.MethodGet && r.URL.Query().Get("includeDeleted") == "true" && isAdmin(r) {
+		scope.IncludeDeleted = true
+	}
+	scope.RequestID, _ = r.Context().Value("requestID").(string)
+	scope.IP = helper.ClientIP(r)
+	return scope
+}
+
+// selfScope returns the scope of the requests of users on their own user: the scope of the request,
+// but reaching the super-admins, which belong to no organisation, whichever one they selected.
+func selfScope(r *http.Request) repository.Scope {
+	scope := requestScope(r)
+	if isSuperAdmin(r) {
+		scope.TenantID = primitive.NilObjectID
+		scope.AllTenants = true
+	}
+	return scope
+}
+
+// isAdmin reports whether the request was made by an admin or a super-admin.
+func isAdmin(r *http.Request) bool {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	return ok && (claims.Role == "admin" || claims.Role == "superadmin")
+}
+
+// isSuperAdmin reports whether the request was made by a super-admin.
+func isSuperAdmin(r *http.Request) bool {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	return ok && claims.Role == "superadmin"
+}
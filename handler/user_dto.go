@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// userRequest is the body of the requests creating or replacing a user. The password is write-only:
+// it is never part of a response, and an empty one keeps the current password on updates.
+type userRequest struct {
+	Email      string             `json:"email" validate:"required,email"`
+	Password   string             `json:"password"`
+	FirstName  string             `json:"first_name" validate:"required"`
+	LastName   string             `json:"last_name" validate:"required"`
+	Role       string             `json:"role" validate:"required,oneof=manager admin supplier superadmin"`
+	SupplierID primitive.ObjectID `json:"supplier"`
+	TenantID   primitive.ObjectID `json:"tenant"`
+}
+
+// newUserRequest returns the request replacing a user by itself, without its password, for merge patches.
+func newUserRequest(user *model.User) *userRequest {
+	return &userRequest{
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		SupplierID: user.SupplierID,
+		TenantID:   user.TenantID,
+	}
+}
+
+func (req *userRequest) toModel() *model.User {
+	return &model.User{
+		Email:      req.Email,
+		Password:   req.Password,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Role:       req.Role,
+		SupplierID: req.SupplierID,
+		TenantID:   req.TenantID,
+	}
+}
+
+// userResponse is a user as the API returns it, without its password hash and token claims.
+type userResponse struct {
+	ID         primitive.ObjectID  `json:"id"`
+	Email      string              `json:"email"`
+	FirstName  string              `json:"first_name"`
+	LastName   string              `json:"last_name"`
+	Role       string              `json:"role"`
+	SupplierID *primitive.ObjectID `json:"supplier,omitempty"`
+	TenantID   *primitive.ObjectID `json:"tenant,omitempty"`
+	DeletedAt  *time.Time          `json:"deletedAt,omitempty"`
+	DeletedBy  *primitive.ObjectID `json:"deletedBy,omitempty"`
+	Version    int                 `json:"version"`
+}
+
+func newUserResponse(user *model.User) userResponse {
+	return userResponse{
+		ID:         user.ID,
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		SupplierID: optionalID(user.SupplierID),
+		TenantID:   optionalID(user.TenantID),
+		DeletedAt:  user.DeletedAt,
+		DeletedBy:  optionalID(user.DeletedBy),
+		Version:    user.Version,
+	}
+}
+
+func newUserResponses(users []model.User) []userResponse {
+	responses := make([]userResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, newUserResponse(&users[i]))
+	}
+	return responses
+}
+
+// profileRequest is the body of the requests of users changing their own profile. The email, role and
+// organisation of a user are managed by admins, and its password through a password change.
+type profileRequest struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+}
+
+// newProfileRequest returns the request replacing the profile of a user by itself, for merge patches.
+func newProfileRequest(user *model.User) *profileRequest {
+	return &profileRequest{FirstName: user.FirstName, LastName: user.LastName}
+}
+
+// applyTo changes the profile of a user.
+func (req *profileRequest) applyTo(user *model.User) {
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+}
+
+// passwordChangeRequest is the body of the requests of users changing their own password. The new password
+// needs at least 8 characters, and must differ from the current one.
+type passwordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8"`
+}
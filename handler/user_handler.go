@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -20,20 +20,24 @@ func NewUserHandler(r repository.UserRepository) *UserHandler {
 
 // CreateUserHandler handles requests to create a new user.
 func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
-	var user model.User
-	err := json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request userRequest
+	if !decodeRequest(w, r, "user", &request) {
+		return
+	}
+	user := request.toModel()
+
+	if user.Role == "superadmin" && !isSuperAdmin(r) {
+		helper.RespondProblem(w, http.StatusForbidden, model.ErrorCodeForbidden, "only super-admins can grant the superadmin role")
 		return
 	}
 
-	err = h.ur.CreateUser(&user)
+	err := h.ur.WithScope(requestScope(r)).CreateUser(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, user)
+	helper.RespondJSON(w, newUserResponse(user))
 }
 
 // GetUserHandler handles requests to retrieve a user by ID.
@@ -41,93 +45,140 @@ func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userID := params["id"]
 
-	user, err := h.ur.GetUserByID(userID)
+	user, err := h.ur.WithScope(requestScope(r)).GetUserByID(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	if user == nil {
-		http.NotFound(w, r)
-		return
-	}
-
-	helper.RespondJSON(w, user)
+	setETag(w, user.Version)
+	helper.RespondJSON(w, newUserResponse(user))
 }
 
 // UpdateUserHandler handles requests to update a user by ID.
 func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	var updatedUser model.User
-	err := json.NewDecoder(r.Body).Decode(&updatedUser)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request userRequest
+	if !decodeRequest(w, r, "user", &request) {
+		return
+	}
+	updatedUser := request.toModel()
+
+	if updatedUser.Role == "superadmin" && !isSuperAdmin(r) {
+		helper.RespondProblem(w, http.StatusForbidden, model.ErrorCodeForbidden, "only super-admins can grant the superadmin role")
 		return
 	}
 
-	err = h.ur.UpdateUser(userID, &updatedUser)
+	err := h.ur.WithScope(requestScope(r)).UpdateUser(userID, updatedUser, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, updatedUser)
+	setETag(w, version+1)
+	helper.RespondJSON(w, newUserResponse(updatedUser))
+}
+
+// PatchUserHandler handles requests to partially update an existing user with a JSON merge patch.
+func (h *UserHandler) PatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	user, err := h.ur.WithScope(requestScope(r)).GetUserByID(userID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	// An omitted password keeps the current one
+	if !mergePatch(w, r, newUserRequest(user)) {
+		return
+	}
+	h.UpdateUserHandler(w, r)
 }
 
 // DeleteUserHandler handles requests to delete a user by ID.
 func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userID := params["id"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := h.ur.DeleteUser(userID)
+	err := h.ur.WithScope(requestScope(r)).DeleteUser(userID, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	helper.RespondJSON(w, map[string]string{"message": "User deleted successfully"})
 }
 
+// RestoreUserHandler handles requests to restore a deleted user by ID.
+func (h *UserHandler) RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userID := params["id"]
+
+	err := h.ur.WithScope(requestScope(r)).RestoreUser(userID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	helper.RespondJSON(w, map[string]string{"message": "User restored successfully"})
+}
+
 // ListUsersHandler handles requests to retrieve a list of all users.
 func (h *UserHandler) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
-	users, err := h.ur.ListAll()
+	users, err := h.ur.WithScope(requestScope(r)).ListAll()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, users)
+	helper.RespondJSON(w, newUserResponses(*users))
 }
 
 // LoginHandler handles requests for user login and generates an authentication token.
 func (h *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
-	var user model.User
-	err := json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var credentials struct {
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"required"`
+	}
+	if !decodeRequest(w, r, "login", &credentials) {
+		return
+	}
+	user := model.User{Email: credentials.Email, Password: credentials.Password}
+	err := h.ur.WithScope(requestScope(r)).ValidateUserCredentials(&user)
+	if errors.Is(err, repository.ErrInvalidCredentials) {
+		helper.RespondProblem(w, http.StatusUnauthorized, model.ErrorCodeUnauthorized, err.Error())
 		return
 	}
-	if err := h.ur.ValidateUserCredentials(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
-	dbUser, err := h.ur.GetUserByEmail(user.Email)
+	dbUser, err := h.ur.WithScope(requestScope(r)).GetUserByEmail(user.Email)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	// Generate authentication token
-	refreshToken, accessToken, err := h.ur.GetTokens(dbUser)
+	refreshToken, accessToken, err := h.ur.WithScope(requestScope(r)).GetTokens(dbUser)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		respondError(w, err)
 		return
 	}
 
-	helper.RespondJSON(w, map[string]string{"refresh_token": refreshToken, "access_token": accessToken})
+	respondTokens(w, refreshToken, accessToken)
 }
 
 func (h *UserHandler) RenewTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -136,17 +187,104 @@ func (h *UserHandler) RenewTokenHandler(w http.ResponseWriter, r *http.Request)
 	refreshToken := r.Header.Get("Authorization")
 
 	// Renew tokens for the user
-	newAccessToken, newRefreshToken, err := h.ur.RenewTokens(userID, refreshToken)
+	newAccessToken, newRefreshToken, err := h.ur.WithScope(requestScope(r)).RenewTokens(userID, refreshToken)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
 	// Respond with the new tokens
-	helper.RespondJSON(w, map[string]string{
-		"access_token":  newAccessToken,
-		"refresh_token": newRefreshToken,
-	})
+	respondTokens(w, newRefreshToken, newAccessToken)
+}
+
+// respondTokens answers a pair of tokens, which must be kept neither by caches nor for idempotent retries.
+func respondTokens(w http.ResponseWriter, refreshToken string, accessToken string) {
+	w.Header().Set("Cache-Control", "no-store")
+	helper.RespondJSON(w, map[string]string{"refresh_token": refreshToken, "access_token": accessToken})
+}
+
+// GetProfileHandler handles requests of users to retrieve their own user.
+func (h *UserHandler) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+
+	user, err := h.ur.WithScope(selfScope(r)).GetUserByID(claims.UserID.Hex())
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, user.Version)
+	helper.RespondJSON(w, newUserResponse(user))
+}
+
+// PatchProfileHandler handles requests of users to change their own name with a JSON merge patch.
+func (h *UserHandler) PatchProfileHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	ur := h.ur.WithScope(selfScope(r))
+	user, err := ur.GetUserByID(claims.UserID.Hex())
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	if !mergePatch(w, r, newProfileRequest(user)) {
+		return
+	}
+	var request profileRequest
+	if !decodeRequest(w, r, "profile", &request) {
+		return
+	}
+	request.applyTo(user)
+
+	err = ur.UpdateUser(user.ID.Hex(), user, version)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	setETag(w, version+1)
+	helper.RespondJSON(w, newUserResponse(user))
+}
+
+// ChangePasswordHandler handles requests of users to change their own password, which requires the current one.
+// The other sessions of the user are closed, and the tokens of the current session are issued again.
+func (h *UserHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("userClaims").(*model.Claims)
+	if !ok {
+		respondMissingClaims(w)
+		return
+	}
+	var request passwordChangeRequest
+	if !decodeRequest(w, r, "password change", &request) {
+		return
+	}
+
+	ur := h.ur.WithScope(selfScope(r))
+	user, err := ur.ChangePassword(claims.UserID.Hex(), request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	refreshToken, accessToken, err := ur.GetTokens(user)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respondTokens(w, refreshToken, accessToken)
 }
 
 /*
@@ -158,9 +296,9 @@ func (h *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.ur.RevokeAuthToken(userID)
+	err := h.ur.WithScope(requestScope(r)).RevokeAuthToken(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err)
 		return
 	}
 
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// versionResponse is a version of a record as the API returns it, the record being in the same shape
+// as when it is retrieved.
+type versionResponse struct {
+	Entity    string              `json:"entity"`
+	EntityID  primitive.ObjectID  `json:"entityId"`
+	Number    int                 `json:"number"`
+	Action    string              `json:"action"`
+	ActorID   *primitive.ObjectID `json:"actor,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+	Document  interface{}         `json:"document"`
+}
+
+func newVersionResponses(versions []model.Version) []versionResponse {
+	responses := make([]versionResponse, 0, len(versions))
+	for _, version := range versions {
+		responses = append(responses, versionResponse{
+			Entity:    version.Entity,
+			EntityID:  version.EntityID,
+			Number:    version.Number,
+			Action:    version.Action,
+			ActorID:   optionalID(version.ActorID),
+			Timestamp: version.Timestamp,
+			Document:  newDocumentResponse(version.Document),
+		})
+	}
+	return responses
+}
+
+// newDocumentResponse maps the record kept by a version to its response.
+func newDocumentResponse(document interface{}) interface{} {
+	switch document := document.(type) {
+	case *model.Supplier:
+		return newSupplierResponse(document)
+	case *model.Location:
+		return newLocationResponse(document)
+	case *model.Purchase:
+		return newPurchaseResponse(document)
+	}
+	return document
+}
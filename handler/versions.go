@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// parseAsOf reads the asOf query parameter, a date or a date and time, asking for the state an entity was in
+// at that time. A date stands for its midnight. asOf is false when the parameter is absent.
+func parseAsOf(r *http.Request) (at time.Time, asOf bool, err error) {
+	value := r.URL.Query().Get("asOf")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	at, err = time.Parse(time.DateOnly, value)
+	if err != nil {
+		at, err = time.Parse(time.RFC3339, value)
+	}
+	return at, true, err
+}
+
+// versionNumber reads the version number path parameter.
+func versionNumber(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["number"])
+}
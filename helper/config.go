@@ -0,0 +1,44 @@
+package helper
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnv returns the value of the environment variable key, or fallback when it is unset.
+func GetEnv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvDuration returns the environment variable key parsed as a duration, or fallback when it is unset or invalid.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration %q for %s, using %s\n", value, key, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// GetEnvFloat returns the environment variable key parsed as a float, or fallback when it is unset or invalid.
+func GetEnvFloat(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number %q for %s, using %v\n", value, key, fallback)
+		return fallback
+	}
+	return number
+}
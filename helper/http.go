@@ -3,25 +3,59 @@ package helper
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SuperAdminAuthorizationMiddleware only lets super-admins through, for the management of organisations.
+func SuperAdminAuthorizationMiddleware(next http.Handler) http.Handler {
+	return authorizationMiddleware(next)
+}
+
 func AdminAuthorizationMiddleware(next http.Handler) http.Handler {
 	return authorizationMiddleware(next, "admin")
 }
 
 func ManagerAuthorizationMiddleware(next http.Handler) http.Handler {
-	return authorizationMiddleware(next, "manager")
+	return authorizationMiddleware(next, "admin", "manager")
+}
+
+// SupplierAuthorizationMiddleware lets admins and supplier users through.
+// Handlers are responsible for scoping supplier users to their own supplier.
+func SupplierAuthorizationMiddleware(next http.Handler) http.Handler {
+	return authorizationMiddleware(next, "admin", "supplier")
+}
+
+// ManagerOrSupplierAuthorizationMiddleware lets admins, managers and supplier users through.
+// Handlers are responsible for scoping supplier users to their own supplier.
+func ManagerOrSupplierAuthorizationMiddleware(next http.Handler) http.Handler {
+	return authorizationMiddleware(next, "admin", "manager", "supplier")
 }
 
-func authorizationMiddleware(next http.Handler, role string) http.Handler {
+// SessionCheck tells whether the session the claims of a token were issued for is still open. It is set at
+// startup to compare the session version of the token with the one of its user, which changes with its password.
+var SessionCheck = func(claims *model.Claims) (bool, error) {
+	return true, nil
+}
+
+// AuthenticatedMiddleware wraps the handlers of the requests made by authenticated users, once their claims are
+// known. It is set at startup to make these requests idempotent.
+var AuthenticatedMiddleware = func(next http.Handler) http.Handler {
+	return next
+}
+
+func authorizationMiddleware(next http.Handler, roles ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract the token from the Authorization header
 		tokenString := extractTokenFromHeader(r)
 		if tokenString == "" {
 			// Token is missing, respond with an authentication error
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			RespondProblem(w, http.StatusUnauthorized, model.ErrorCodeUnauthorized, "missing bearer token")
 			return
 		}
 
@@ -29,37 +63,174 @@ func authorizationMiddleware(next http.Handler, role string) http.Handler {
 		claims, _, err := VerifyToken(tokenString)
 		if err != nil {
 			// Token is invalid, respond with an authentication error
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			RespondProblem(w, http.StatusUnauthorized, model.ErrorCodeUnauthorized, "invalid or expired token")
+			return
+		}
+		open, err := SessionCheck(claims)
+		if err != nil {
+			log.Printf("Checking the session of user %s failed: %v\n", claims.UserID.Hex(), err)
+			RespondProblem(w, http.StatusInternalServerError, model.ErrorCodeInternal, "an unexpected error occurred")
+			return
+		}
+		if !open {
+			RespondProblem(w, http.StatusUnauthorized, model.ErrorCodeUnauthorized, "the session was closed, log in again")
 			return
 		}
 
-		if !isRoleAllowedToAccess(claims.Role, role) {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if !isRoleAllowedToAccess(claims.Role, roles) {
+			RespondProblem(w, http.StatusForbidden, model.ErrorCodeForbidden, "the role "+claims.Role+" cannot access this resource")
 			return
 		}
+
+		// Super-admins act on every organisation, unless they select one with the X-Tenant-ID header
+		if claims.Role == "superadmin" {
+			claims.TenantID = primitive.NilObjectID
+			if tenant := r.Header.Get("X-Tenant-ID"); tenant != "" {
+				claims.TenantID, err = primitive.ObjectIDFromHex(tenant)
+				if err != nil {
+					RespondProblem(w, http.StatusBadRequest, model.ErrorCodeMalformedRequest, "invalid X-Tenant-ID header")
+					return
+				}
+			}
+		}
 		ctx := context.WithValue(r.Context(), "userClaims", claims)
 		r = r.WithContext(ctx)
 		// Token is valid, proceed to the next handler
-		next.ServeHTTP(w, r)
+		AuthenticatedMiddleware(next).ServeHTTP(w, r)
+	})
+}
+
+// RequestIDMiddleware tags every request with an ID, taken from the X-Request-ID header or generated,
+// and sends it back in the response so that the request can be traced in the audit log.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID, _ = GenerateRandomToken()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := context.WithValue(r.Context(), "requestID", requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// TrustedProxies lists the networks of the proxies whose X-Forwarded-For header is believed. It is set
+// at startup from TRUSTED_PROXIES; without it, the header is ignored.
+var TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma separated list of IP addresses and CIDR networks.
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIP returns the address of the client. Behind trusted proxies, it is the last address of the
+// X-Forwarded-For header which was not added by one of them, since the client can forge the ones before.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trustedProxy(host) {
+		return host
+	}
+	forwardedFor := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwardedFor[i])
+		if address == "" {
+			continue
+		}
+		if !trustedProxy(address) {
+			return address
+		}
+		host = address
+	}
+	return host
+}
+
+// trustedProxy tells whether the address belongs to one of the trusted proxies.
+func trustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // respondJSON is a helper function to respond with JSON data.
 func RespondJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
 
-func isRoleAllowedToAccess(role string, targetRole string) bool {
-	if role == "admin" {
-		return true
+// RespondProblem responds with the problem details of an error (RFC 7807), identified by a stable code.
+func RespondProblem(w http.ResponseWriter, status int, code string, detail string) {
+	WriteProblem(w, model.Problem{Status: status, Code: code, Detail: detail})
+}
+
+// WriteProblem responds with problem details, titled after their status unless they have a title.
+func WriteProblem(w http.ResponseWriter, problem model.Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
 	}
-	if role == "manager" && targetRole == "manager" {
+	w.Header().Set("Content-Type", model.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// isRoleAllowedToAccess tells whether the role is one of the target roles.
+// Super-admins are allowed everywhere.
+func isRoleAllowedToAccess(role string, targetRoles []string) bool {
+	if role == "superadmin" {
 		return true
 	}
+	for _, targetRole := range targetRoles {
+		if role == targetRole {
+			return true
+		}
+	}
 	return false
 }
 
+// RequestClaims returns the claims of the token in the Authorization header, if it is valid.
+func RequestClaims(r *http.Request) (*model.Claims, bool) {
+	tokenString := extractTokenFromHeader(r)
+	if tokenString == "" {
+		return nil, false
+	}
+	claims, _, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
 func extractTokenFromHeader(r *http.Request) string {
 	authorizationHeader := r.Header.Get("Authorization")
 	if authorizationHeader == "" {
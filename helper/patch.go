@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MergePatch applies a JSON merge patch, as defined by RFC 7396, to a JSON document.
+// The members of the patch replace the members of the document, objects being merged
+// recursively, and null members remove them.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decodeJSON(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// decodeJSON decodes a JSON document, keeping its numbers as they are written.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
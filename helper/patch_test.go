@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// The cases of the appendix A of RFC 7396, followed by the ones specific to the API.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		document string
+		patch    string
+		want     string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"price":10.50,"stock":3}`, `{"stock":4}`, `{"price":10.50,"stock":4}`},
+		{`{"contacts":[{"name":"a"},{"name":"b"}]}`, `{"contacts":[{"name":"c"}]}`, `{"contacts":[{"name":"c"}]}`},
+		{`{"address":{"city":"Paris","country":"FR"}}`, `{"address":{"city":"Lyon"}}`, `{"address":{"city":"Lyon","country":"FR"}}`},
+	}
+	for _, test := range tests {
+		got, err := MergePatch([]byte(test.document), []byte(test.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s) failed: %v", test.document, test.patch, err)
+			continue
+		}
+		if want := compact(t, test.want); string(got) != want {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", test.document, test.patch, got, want)
+		}
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	tests := []struct {
+		document string
+		patch    string
+	}{
+		{`{"a":`, `{}`},
+		{`{}`, `{"a"}`},
+		{`{}`, ``},
+	}
+	for _, test := range tests {
+		if _, err := MergePatch([]byte(test.document), []byte(test.patch)); err == nil {
+			t.Errorf("MergePatch(%q, %q) succeeded, want an error", test.document, test.patch)
+		}
+	}
+}
+
+// compact returns a JSON document as json.Marshal writes it, with its keys sorted.
+func compact(t *testing.T, document string) string {
+	value, err := decodeJSON([]byte(document))
+	if err != nil {
+		t.Fatalf("invalid document %s: %v", document, err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("invalid document %s: %v", document, err)
+	}
+	return string(data)
+}
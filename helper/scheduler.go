@@ -0,0 +1,24 @@
+package helper
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Schedule runs job every interval until ctx is cancelled. Failures are logged and the job keeps running.
+func Schedule(ctx context.Context, name string, interval time.Duration, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(); err != nil {
+				log.Printf("Scheduled job %s failed: %v\n", name, err)
+			}
+		}
+	}
+}
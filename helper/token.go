@@ -1,6 +1,8 @@
 package helper
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -16,8 +18,11 @@ var refreshTokenExpirationTime = time.Hour * 12
 
 func GenerateAccessToken(user *model.User) (string, error) {
 	claims := model.Claims{
-		UserID: user.ID,
-		Role:   user.Role,
+		UserID:         user.ID,
+		Role:           user.Role,
+		SupplierID:     user.SupplierID,
+		TenantID:       user.TenantID,
+		SessionVersion: user.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenExpirationTime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -33,8 +38,11 @@ func GenerateAccessToken(user *model.User) (string, error) {
 
 func GenerateRefreshToken(user *model.User) (string, error) {
 	claims := model.Claims{
-		UserID: user.ID,
-		Role:   user.Role,
+		UserID:         user.ID,
+		Role:           user.Role,
+		SupplierID:     user.SupplierID,
+		TenantID:       user.TenantID,
+		SessionVersion: user.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(refreshTokenExpirationTime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -90,3 +98,12 @@ func VerifyToken(tokenString string) (*model.Claims, bool, error) {
 
 	return claims, needsRefresh, nil
 }
+
+// GenerateRandomToken returns a random hexadecimal token, suitable for links shared by email.
+func GenerateRandomToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
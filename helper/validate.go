@@ -0,0 +1,186 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+// DecodeJSON reads a JSON request body into v, refusing the fields v does not have.
+func DecodeJSON(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Validate checks a value against the rules of the validate tags of its fields, and of the fields
+// of the structs, pointers and slices it holds, and returns the error of every field breaking a rule.
+// Fields are named by their JSON path, such as contacts[0].email. The rules, separated by commas, are:
+//
+//	required     the field is not empty, and a string not blank
+//	email        a non empty string is an email address
+//	min=N, max=N a number is at least or at most N, a slice has at least or at most N items,
+//	             and a string at least or at most N characters
+//	gt=N         a number is greater than N
+//	oneof=a b c  a non empty string is one of the values separated by spaces
+func Validate(v interface{}) []model.FieldError {
+	var errors []model.FieldError
+	validateValue(reflect.ValueOf(v), "", &errors)
+	return errors
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func validateValue(value reflect.Value, path string, errors *[]model.FieldError) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			validateValue(value.Elem(), path, errors)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			// The fields of embedded structs are promoted, whether the struct type is exported or not
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			fieldPath := path
+			if name == "" && !field.Anonymous {
+				name = field.Name
+			}
+			if name != "" {
+				fieldPath = joinPath(path, name)
+			}
+			if rules := field.Tag.Get("validate"); rules != "" {
+				for _, rule := range strings.Split(rules, ",") {
+					if message := checkRule(value.Field(i), rule); message != "" {
+						*errors = append(*errors, model.FieldError{Field: fieldPath, Message: message})
+						break
+					}
+				}
+			}
+			validateValue(value.Field(i), fieldPath, errors)
+		}
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// checkRule returns why the value breaks the rule, or an empty string when it does not.
+func checkRule(value reflect.Value, rule string) string {
+	name, parameter, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" || value.IsZero() ||
+			value.Kind() == reflect.Slice && value.Len() == 0 {
+			return "is required"
+		}
+	case "email":
+		if value.String() != "" {
+			if _, err := mail.ParseAddress(value.String()); err != nil {
+				return "is not a valid email address"
+			}
+		}
+	case "oneof":
+		values := strings.Fields(parameter)
+		if value.String() != "" && !contains(values, value.String()) {
+			return "must be one of " + strings.Join(values, ", ")
+		}
+	case "min", "max", "gt":
+		bound, err := strconv.ParseFloat(parameter, 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate rule %q", rule))
+		}
+		if value.Kind() == reflect.Slice {
+			return checkLength(value.Len(), name, bound)
+		}
+		if value.Kind() == reflect.String {
+			return checkCharacters(utf8.RuneCountInString(value.String()), name, bound)
+		}
+		return checkBound(number(value), name, bound, parameter)
+	default:
+		panic(fmt.Sprintf("unknown validate rule %q", rule))
+	}
+	return ""
+}
+
+func checkLength(length int, rule string, bound float64) string {
+	switch {
+	case rule == "min" && float64(length) < bound:
+		return fmt.Sprintf("needs at least %g items", bound)
+	case rule == "max" && float64(length) > bound:
+		return fmt.Sprintf("accepts at most %g items", bound)
+	case rule == "gt" && float64(length) <= bound:
+		return fmt.Sprintf("needs more than %g items", bound)
+	}
+	return ""
+}
+
+func checkCharacters(length int, rule string, bound float64) string {
+	switch {
+	case rule == "min" && float64(length) < bound:
+		return fmt.Sprintf("must be at least %g characters long", bound)
+	case rule == "max" && float64(length) > bound:
+		return fmt.Sprintf("must be at most %g characters long", bound)
+	case rule == "gt" && float64(length) <= bound:
+		return fmt.Sprintf("must be longer than %g characters", bound)
+	}
+	return ""
+}
+
+func checkBound(number float64, rule string, bound float64, parameter string) string {
+	switch {
+	case rule == "min" && number < bound:
+		return "must be at least " + parameter
+	case rule == "max" && number > bound:
+		return "must be at most " + parameter
+	case rule == "gt" && number <= bound:
+		return "must be greater than " + parameter
+	}
+	return ""
+}
+
+func number(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	panic(fmt.Sprintf("numeric validate rule on a %s", value.Kind()))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+type testContact struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"email"`
+}
+
+type testAudit struct {
+	Reference string `json:"reference" validate:"required"`
+}
+
+type testOrder struct {
+	testAudit
+	Quantity int `json:"quantity" validate:"gt=0"`
+}
+
+type testCustomer struct {
+	Contacts []testContact `json:"contacts" validate:"min=1"`
+	Primary  *testContact  `json:"primary"`
+	Billing  testContact
+}
+
+type testRole struct {
+	Role string `json:"role" validate:"required,oneof=admin manager"`
+}
+
+type testPrice struct {
+	Price float64 `json:"price" validate:"min=0,max=100"`
+}
+
+type testCode struct {
+	Code string   `json:"code" validate:"max=4"`
+	Tags []string `json:"tags" validate:"max=2"`
+}
+
+type testDeadline struct {
+	Name     string    `json:"name,omitempty" validate:"required"`
+	Deadline time.Time `json:"deadline" validate:"required"`
+	Secret   string    `json:"-" validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	deadline := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []model.FieldError
+	}{
+		{"required", &testDeadline{Name: "Acme", Deadline: deadline, Secret: "s"}, nil},
+		{"blank string", &testDeadline{Name: " ", Deadline: deadline, Secret: "s"}, []model.FieldError{{Field: "name", Message: "is required"}}},
+		{"zero time", &testDeadline{Name: "Acme", Secret: "s"}, []model.FieldError{{Field: "deadline", Message: "is required"}}},
+		{"field without a JSON name", &testDeadline{Name: "Acme", Deadline: deadline}, nil},
+		{"first broken rule only", &testRole{}, []model.FieldError{{Field: "role", Message: "is required"}}},
+		{"oneof", &testRole{Role: "owner"}, []model.FieldError{{Field: "role", Message: "must be one of admin, manager"}}},
+		{"gt", &testOrder{testAudit: testAudit{Reference: "PO-1"}}, []model.FieldError{{Field: "quantity", Message: "must be greater than 0"}}},
+		{"min", &testPrice{Price: -1}, []model.FieldError{{Field: "price", Message: "must be at least 0"}}},
+		{"max", &testPrice{Price: 100.5}, []model.FieldError{{Field: "price", Message: "must be at most 100"}}},
+		{"bounds included", &testPrice{Price: 100}, nil},
+		{"max characters", &testCode{Code: "ABCDE"}, []model.FieldError{{Field: "code", Message: "must be at most 4 characters long"}}},
+		{"characters counted", &testCode{Code: "ÉÈÊË"}, nil},
+		{"max items", &testCode{Tags: []string{"a", "b", "c"}}, []model.FieldError{{Field: "tags", Message: "accepts at most 2 items"}}},
+		{"min items", &testCustomer{Billing: testContact{Name: "Jane"}}, []model.FieldError{{Field: "contacts", Message: "needs at least 1 items"}}},
+		{"email", &testContact{Name: "Jane", Email: "jane@acme.example"}, nil},
+		{"slice items", &testCustomer{
+			Contacts: []testContact{{Name: "Jane"}, {Email: "john"}},
+			Billing:  testContact{Name: "Jane"},
+		}, []model.FieldError{
+			{Field: "contacts[1].name", Message: "is required"},
+			{Field: "contacts[1].email", Message: "is not a valid email address"},
+		}},
+		{"pointer", &testCustomer{
+			Contacts: []testContact{{Name: "Jane"}},
+			Primary:  &testContact{},
+			Billing:  testContact{Name: "Jane"},
+		}, []model.FieldError{{Field: "primary.name", Message: "is required"}}},
+		{"untagged field", &testCustomer{Contacts: []testContact{{Name: "Jane"}}}, []model.FieldError{{Field: "Billing.name", Message: "is required"}}},
+		{"embedded struct", &testOrder{Quantity: 1}, []model.FieldError{{Field: "reference", Message: "is required"}}},
+		{"every field", &testDeadline{Secret: "s"}, []model.FieldError{
+			{Field: "name", Message: "is required"},
+			{Field: "deadline", Message: "is required"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Validate(test.value); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Validate() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"unknown rule", struct {
+			Name string `validate:"unique"`
+		}{}},
+		{"bound not a number", struct {
+			Quantity int `validate:"min=one"`
+		}{}},
+		{"bound on a bool", struct {
+			Active bool `validate:"min=1"`
+		}{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Validate() did not panic")
+				}
+			}()
+			Validate(test.value)
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr bool
+	}{
+		{`{"name":"Jane","email":"jane@acme.example"}`, false},
+		{`{"name":"Jane","phone":"0123"}`, true},
+		{`{"name":`, true},
+	}
+	for _, test := range tests {
+		var contact testContact
+		err := DecodeJSON(strings.NewReader(test.body), &contact)
+		if (err != nil) != test.wantErr {
+			t.Errorf("DecodeJSON(%s) = %v, want error %v", test.body, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditEntry records a single write made to an entity: who made it, from which request,
+// and the fields it changed. Entries are append-only.
+type AuditEntry struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID     `json:"tenant" bson:"tenantId,omitempty"`
+	ActorID   primitive.ObjectID     `json:"actor" bson:"actor,omitempty"`
+	Action    string                 `json:"action"`
+	Entity    string                 `json:"entity"`
+	EntityID  interface{}            `json:"entityId" bson:"entityId"`
+	Changes   map[string]AuditChange `json:"changes"`
+	Timestamp time.Time              `json:"timestamp"`
+	RequestID string                 `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	IP        string                 `json:"ip,omitempty" bson:"ip,omitempty"`
+}
+
+// AuditChange holds the values of a field before and after a write.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -0,0 +1,40 @@
+package model
+
+const (
+	BulkActionCreate = "create"
+	BulkActionUpdate = "update"
+	BulkActionDelete = "delete"
+)
+
+const (
+	BulkStatusDone       = "done"
+	BulkStatusFailed     = "failed"
+	BulkStatusRolledBack = "rolledBack"
+	BulkStatusSkipped    = "skipped"
+)
+
+// LocationOperation is one of the operations of a bulk request on locations. Updates and deletes
+// name the location by ID and give the version they expect; creates and updates carry the location.
+type LocationOperation struct {
+	Action   string    `json:"action"`
+	ID       string    `json:"id,omitempty"`
+	Version  int       `json:"version"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// PurchaseOperation is one of the operations of a bulk request on purchases, like LocationOperation.
+type PurchaseOperation struct {
+	Action   string    `json:"action"`
+	ID       string    `json:"id,omitempty"`
+	Version  int       `json:"version"`
+	Purchase *Purchase `json:"purchase,omitempty"`
+}
+
+// BulkResult is the outcome of one of the operations of a bulk request. When the request is atomic,
+// the operations made before a failed one are rolled back and the following ones are skipped.
+type BulkResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
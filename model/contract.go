@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ContractPolicyFlag  = "flag"
+	ContractPolicyBlock = "block"
+)
+
+// ContractPrice is the price agreed for one location of the supplier.
+type ContractPrice struct {
+	LocationID primitive.ObjectID `json:"location" bson:"location"`
+	Price      float64            `json:"price"`
+}
+
+// Contract holds the terms agreed with a supplier for a period of time.
+type Contract struct {
+	ID                   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID             primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	SupplierID           primitive.ObjectID `json:"supplier" bson:"supplier"`
+	StartDate            time.Time          `json:"startDate" bson:"startDate"`
+	EndDate              time.Time          `json:"endDate" bson:"endDate"`
+	Prices               []ContractPrice    `json:"prices"`
+	MinimumOrderQuantity int                `json:"minimumOrderQuantity" bson:"minimumOrderQuantity"`
+	PaymentTerms         string             `json:"paymentTerms" bson:"paymentTerms"`
+	DeletedAt            *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy            primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version              int                `json:"version" bson:"version"`
+}
+
+// PriceFor returns the price locked by the contract for a location, if any.
+func (c *Contract) PriceFor(locationID primitive.ObjectID) (float64, bool) {
+	for _, price := range c.Prices {
+		if price.LocationID == locationID {
+			return price.Price, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	IdempotencyStatusInFlight  = "in-flight"
+	IdempotencyStatusCompleted = "completed"
+)
+
+// IdempotentRequest is a request made with an Idempotency-Key header, along with its response once completed,
+// so that the retries of the request replay the response instead of being processed again.
+type IdempotentRequest struct {
+	Key         string    `json:"key" bson:"_id"`
+	Fingerprint string    `json:"fingerprint"`
+	Status      string    `json:"status"`
+	StatusCode  int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	ContentType string    `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Body        []byte    `json:"body,omitempty" bson:"body,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+	LockedUntil time.Time `json:"lockedUntil" bson:"lockedUntil,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt" bson:"expiresAt"`
+}
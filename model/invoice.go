@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	InvoiceStatusPayable   = "payable"
+	InvoiceStatusException = "exception"
+	InvoiceStatusRejected  = "rejected"
+)
+
+// InvoiceLine bills a quantity of one purchase.
+type InvoiceLine struct {
+	PurchaseID primitive.ObjectID `json:"purchase" bson:"purchase"`
+	Quantity   int                `json:"quantity"`
+	Amount     float64            `json:"amount"`
+}
+
+// Invoice is a supplier invoice, matched against the purchases and receipts it bills.
+type Invoice struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID      primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	Number        string             `json:"number"`
+	Date          time.Time          `json:"date"`
+	SupplierID    primitive.ObjectID `json:"supplier" bson:"supplier"`
+	Lines         []InvoiceLine      `json:"lines"`
+	Total         float64            `json:"total"`
+	Status        string             `json:"status"`
+	Discrepancies []string           `json:"discrepancies"`
+	ResolvedBy    primitive.ObjectID `json:"resolvedBy" bson:"resolvedBy,omitempty"`
+	DeletedAt     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy     primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version       int                `json:"version" bson:"version"`
+}
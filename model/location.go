@@ -1,11 +1,23 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Location struct {
-	ID       primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	SupplierID primitive.ObjectID  `json:"supplier" bson:"supplier"`
-	SupplierName string  `json:"supplierName" bson:"supplierName"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID        primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	Name            string             `json:"name"`
+	Price           float64            `json:"price"`
+	SupplierID      primitive.ObjectID `json:"supplier" bson:"supplier"`
+	SupplierName    string             `json:"supplierName" bson:"supplierName"`
+	TrackStock      bool               `json:"trackStock" bson:"trackStock"`
+	Stock           int                `json:"stock" bson:"stock"`
+	ReorderPoint    int                `json:"reorderPoint" bson:"reorderPoint"`
+	ReorderQuantity int                `json:"reorderQuantity" bson:"reorderQuantity"`
+	DeletedAt       *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy       primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version         int                `json:"version" bson:"version"`
 }
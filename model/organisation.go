@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organisation is a tenant of the API. Users, suppliers, locations and everything derived
+// from them belong to exactly one organisation and are invisible to the others.
+type Organisation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name"`
+	DeletedAt *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version   int                `json:"version" bson:"version"`
+}
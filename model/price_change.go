@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PriceChangePending  = "pending"
+	PriceChangeApproved = "approved"
+	PriceChangeRejected = "rejected"
+)
+
+// PriceChange is a location price submitted by a supplier user, waiting for an admin to review it.
+type PriceChange struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID       primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	LocationID     primitive.ObjectID `json:"location" bson:"location"`
+	SupplierID     primitive.ObjectID `json:"supplier" bson:"supplier"`
+	CurrentPrice   float64            `json:"currentPrice" bson:"currentPrice"`
+	RequestedPrice float64            `json:"requestedPrice" bson:"requestedPrice"`
+	Status         string             `json:"status"`
+	RequestedBy    primitive.ObjectID `json:"requestedBy" bson:"requestedBy"`
+	RequestedAt    time.Time          `json:"requestedAt" bson:"requestedAt"`
+	ReviewedBy     primitive.ObjectID `json:"reviewedBy" bson:"reviewedBy,omitempty"`
+	ReviewedAt     time.Time          `json:"reviewedAt" bson:"reviewedAt,omitempty"`
+	DeletedAt      *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy      primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version        int                `json:"version" bson:"version"`
+}
@@ -0,0 +1,41 @@
+package model
+
+// Stable codes of the errors, given in the code member of the problem details. Clients can rely on them,
+// unlike the detail, which is meant for humans and can change.
+const (
+	ErrorCodeMalformedRequest        = "malformed_request"
+	ErrorCodeValidationFailed        = "validation_failed"
+	ErrorCodeUnauthorized            = "unauthorized"
+	ErrorCodeForbidden               = "forbidden"
+	ErrorCodeNotFound                = "not_found"
+	ErrorCodeConflict                = "conflict"
+	ErrorCodeStillReferenced         = "still_referenced"
+	ErrorCodeVersionMismatch         = "version_mismatch"
+	ErrorCodeVersionRequired         = "version_required"
+	ErrorCodeIdempotencyKeyReused    = "idempotency_key_reused"
+	ErrorCodeRequestInProgress       = "request_in_progress"
+	ErrorCodeTransactionsUnsupported = "transactions_unsupported"
+	ErrorCodeInternal                = "internal_error"
+)
+
+// ProblemContentType is the media type of the error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem describes an error in an HTTP response, following RFC 7807. Code, Errors and Blockers are extensions:
+// Errors lists the invalid fields of a validation failure, and Blockers the records preventing a delete.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Blockers interface{}  `json:"blockers,omitempty"`
+}
+
+// FieldError tells why a field of a request is invalid. Nested fields are named by their path, such as contacts[0].email.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
@@ -6,15 +6,80 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	PurchaseStatusDraft   = "draft"
+	PurchaseStatusOrdered = "ordered"
+)
+
+const (
+	DeliveryStatusPending  = "pending"
+	DeliveryStatusPartial  = "partial"
+	DeliveryStatusComplete = "complete"
+	DeliveryStatusOver     = "over-delivered"
+	DeliveryStatusUnder    = "under-delivered"
+)
+
 type Purchase struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Quantity     int                `json:"quantity"`
-	Date         time.Time          `json:"date"`
-	Fees         float64            `json:"fees"`
-	TotalPrice   float64            `json:"totalPrice"`
-	UserID       primitive.ObjectID `json:"user" bson:"user"`
-	LocationID   primitive.ObjectID `json:"location" bson:"location"`
-	LocationName string             `json:"locationName" bson:"locationName"`
-	SupplierName string             `json:"supplierName" bson:"supplierName"`
-	UserName 	 string				`json:"userName" bson:"userName"`
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID            primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	Quantity            int                `json:"quantity"`
+	Date                time.Time          `json:"date"`
+	ExpectedDate        time.Time          `json:"expectedDate" bson:"expectedDate"`
+	Fees                float64            `json:"fees"`
+	UnitPrice           float64            `json:"unitPrice" bson:"unitPrice"`
+	TotalPrice          float64            `json:"totalPrice"`
+	Status              string             `json:"status"`
+	ReceivedQuantity    int                `json:"receivedQuantity" bson:"receivedQuantity"`
+	OutstandingQuantity int                `json:"outstandingQuantity" bson:"outstandingQuantity"`
+	DeliveryStatus      string             `json:"deliveryStatus" bson:"deliveryStatus"`
+	DeliveryClosed      bool               `json:"deliveryClosed" bson:"deliveryClosed"`
+	ReturnedQuantity    int                `json:"returnedQuantity" bson:"returnedQuantity"`
+	CreditedAmount      float64            `json:"creditedAmount" bson:"creditedAmount"`
+	UserID              primitive.ObjectID `json:"user" bson:"user"`
+	LocationID          primitive.ObjectID `json:"location" bson:"location"`
+	ContractID          primitive.ObjectID `json:"contract" bson:"contract,omitempty"`
+	OffContract         bool               `json:"offContract" bson:"offContract"`
+	RFQID               primitive.ObjectID `json:"rfq" bson:"rfq,omitempty"`
+	QuotedPrice         float64            `json:"quotedPrice,omitempty" bson:"quotedPrice,omitempty"`
+	LocationName        string             `json:"locationName" bson:"locationName"`
+	SupplierID          primitive.ObjectID `json:"supplier" bson:"supplier"`
+	SupplierName        string             `json:"supplierName" bson:"supplierName"`
+	UserName            string             `json:"userName" bson:"userName"`
+	DeletedAt           *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy           primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version             int                `json:"version" bson:"version"`
+}
+
+// RefreshDelivery recomputes the outstanding quantity and the delivery status from the received quantity.
+// Once the delivery is closed nothing is outstanding anymore, whatever was received.
+func (p *Purchase) RefreshDelivery() {
+	p.OutstandingQuantity = 0
+	if !p.DeliveryClosed && p.ReceivedQuantity < p.Quantity {
+		p.OutstandingQuantity = p.Quantity - p.ReceivedQuantity
+	}
+
+	switch {
+	case p.ReceivedQuantity > p.Quantity:
+		p.DeliveryStatus = DeliveryStatusOver
+	case p.ReceivedQuantity == p.Quantity:
+		p.DeliveryStatus = DeliveryStatusComplete
+	case p.DeliveryClosed:
+		p.DeliveryStatus = DeliveryStatusUnder
+	case p.ReceivedQuantity > 0:
+		p.DeliveryStatus = DeliveryStatusPartial
+	default:
+		p.DeliveryStatus = DeliveryStatusPending
+	}
+}
+
+// UnitCredit returns the amount credited for each returned unit, from the unit price snapshot taken
+// when the purchase was priced. Purchases priced before the snapshot existed fall back on their total.
+func (p *Purchase) UnitCredit() float64 {
+	if p.UnitPrice != 0 {
+		return p.UnitPrice * (1 - p.Fees)
+	}
+	if p.Quantity == 0 {
+		return 0
+	}
+	return p.TotalPrice / float64(p.Quantity)
 }
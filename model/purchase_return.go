@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurchaseReturn records goods sent back to the supplier, with the credit expected in exchange.
+type PurchaseReturn struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID     primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	PurchaseID   primitive.ObjectID `json:"purchase" bson:"purchase"`
+	Quantity     int                `json:"quantity"`
+	Reason       string             `json:"reason"`
+	CreditAmount float64            `json:"creditAmount" bson:"creditAmount"`
+	Date         time.Time          `json:"date"`
+	UserID       primitive.ObjectID `json:"user" bson:"user"`
+	UserName     string             `json:"userName" bson:"userName"`
+	DeletedAt    *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy    primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version      int                `json:"version" bson:"version"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Receipt records goods received for a purchase. A purchase can be delivered over several receipts.
+type Receipt struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID      primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	PurchaseID    primitive.ObjectID `json:"purchase" bson:"purchase"`
+	Quantity      int                `json:"quantity"`
+	Date          time.Time          `json:"date"`
+	Final         bool               `json:"final"`
+	ReceiverID    primitive.ObjectID `json:"receiver" bson:"receiver"`
+	ReceiverName  string             `json:"receiverName" bson:"receiverName"`
+	OverDelivery  bool               `json:"overDelivery" bson:"overDelivery"`
+	UnderDelivery bool               `json:"underDelivery" bson:"underDelivery"`
+	DeletedAt     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy     primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version       int                `json:"version" bson:"version"`
+}
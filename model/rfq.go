@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RFQStatusOpen    = "open"
+	RFQStatusAwarded = "awarded"
+)
+
+// RFQItem is something needed, described independently of any supplier.
+type RFQItem struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+}
+
+// RFQInvitation invites a supplier to quote. The token lets the supplier answer without an account.
+type RFQInvitation struct {
+	SupplierID primitive.ObjectID `json:"supplier" bson:"supplier"`
+	Token      string             `json:"token"`
+}
+
+// QuoteLine prices an item of the RFQ with one of the locations of the supplier.
+type QuoteLine struct {
+	Item       int                `json:"item"`
+	LocationID primitive.ObjectID `json:"location" bson:"location"`
+	UnitPrice  float64            `json:"unitPrice" bson:"unitPrice"`
+}
+
+// Quote is the answer of a supplier to an RFQ.
+type Quote struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	SupplierID   primitive.ObjectID `json:"supplier" bson:"supplier"`
+	Lines        []QuoteLine        `json:"lines"`
+	LeadTimeDays int                `json:"leadTimeDays" bson:"leadTimeDays"`
+	Total        float64            `json:"total"`
+	SubmittedAt  time.Time          `json:"submittedAt" bson:"submittedAt"`
+}
+
+// RFQ is a request for quote sent to several suppliers before a purchase.
+type RFQ struct {
+	ID             primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TenantID       primitive.ObjectID   `json:"tenant" bson:"tenantId,omitempty"`
+	Title          string               `json:"title"`
+	Items          []RFQItem            `json:"items"`
+	Invitations    []RFQInvitation      `json:"invitations"`
+	Deadline       time.Time            `json:"deadline"`
+	Status         string               `json:"status"`
+	Quotes         []Quote              `json:"quotes"`
+	AwardedQuoteID primitive.ObjectID   `json:"awardedQuote" bson:"awardedQuote,omitempty"`
+	PurchaseIDs    []primitive.ObjectID `json:"purchases" bson:"purchases"`
+	DeletedAt      *time.Time           `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy      primitive.ObjectID   `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version        int                  `json:"version" bson:"version"`
+}
+
+// QuoteComparison puts a quote side by side with the others. ItemPrices follows the order
+// of the RFQ items and holds null for the items the supplier did not quote.
+type QuoteComparison struct {
+	QuoteID      primitive.ObjectID `json:"quote"`
+	SupplierID   primitive.ObjectID `json:"supplier"`
+	SupplierName string             `json:"supplierName"`
+	ItemPrices   []*float64         `json:"itemPrices"`
+	Total        float64            `json:"total"`
+	LeadTimeDays int                `json:"leadTimeDays"`
+	Complete     bool               `json:"complete"`
+}
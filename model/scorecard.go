@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scorecard gathers the performance indicators of a supplier over a time window.
+// Rates are between 0 and 1; the price variance is relative to the contract price.
+type Scorecard struct {
+	SupplierID          primitive.ObjectID `json:"supplier" bson:"supplier"`
+	TenantID            primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	From                time.Time          `json:"from"`
+	To                  time.Time          `json:"to"`
+	Purchases           int                `json:"purchases"`
+	OnTimeDeliveryRate  float64            `json:"onTimeDeliveryRate" bson:"onTimeDeliveryRate"`
+	ReturnRate          float64            `json:"returnRate" bson:"returnRate"`
+	PriceVariance       float64            `json:"priceVariance" bson:"priceVariance"`
+	AverageLeadTimeDays float64            `json:"averageLeadTimeDays" bson:"averageLeadTimeDays"`
+	ComputedAt          time.Time          `json:"computedAt" bson:"computedAt"`
+}
@@ -1,10 +1,78 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SupplierStatusOnboarding = "onboarding"
+	SupplierStatusActive     = "active"
+	SupplierStatusSuspended  = "suspended"
+	SupplierStatusBlocked    = "blocked"
+)
+
+const (
+	ContactRoleSales   = "sales"
+	ContactRoleBilling = "billing"
+	ContactRoleSupport = "support"
+)
 
 type Supplier struct {
-	ID    primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TenantID        primitive.ObjectID  `json:"tenant" bson:"tenantId,omitempty"`
+	Name            string              `json:"name"`
+	Phone           string              `json:"phone"`
+	Email           string              `json:"email"`
+	Contacts        []Contact           `json:"contacts"`
+	BillingAddress  Address             `json:"billingAddress" bson:"billingAddress"`
+	ShippingAddress Address             `json:"shippingAddress" bson:"shippingAddress"`
+	TaxID           string              `json:"taxId" bson:"taxId"`
+	BankDetails     BankDetails         `json:"bankDetails" bson:"bankDetails"`
+	PaymentTerms    string              `json:"paymentTerms" bson:"paymentTerms"`
+	Status          string              `json:"status"`
+	Onboarding      OnboardingChecklist `json:"onboarding"`
+	DeletedAt       *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy       primitive.ObjectID  `json:"deletedBy" bson:"deletedBy,omitempty"`
+	Version         int                 `json:"version" bson:"version"`
+}
+
+// OnboardingChecklist tracks what is needed before a supplier can be activated.
+type OnboardingChecklist struct {
+	DocumentsReceived bool `json:"documentsReceived" bson:"documentsReceived"`
+	TaxIDVerified     bool `json:"taxIdVerified" bson:"taxIdVerified"`
+}
+
+// Complete reports whether every item of the checklist is done.
+func (c OnboardingChecklist) Complete() bool {
+	return c.DocumentsReceived && c.TaxIDVerified
+}
+
+// CanBeOrderedFrom reports whether purchases can be placed with the supplier.
+// Suppliers created before the lifecycle existed have no status and stay orderable.
+func (s *Supplier) CanBeOrderedFrom() bool {
+	return s.Status != SupplierStatusSuspended && s.Status != SupplierStatusBlocked
+}
+
+// Contact is a person to reach at the supplier for a given role.
+type Contact struct {
 	Name  string `json:"name"`
-	Phone string `json:"phone"`
+	Role  string `json:"role"`
 	Email string `json:"email"`
-}
\ No newline at end of file
+	Phone string `json:"phone"`
+}
+
+// Address is a postal address. Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode" bson:"postalCode"`
+	Country    string `json:"country"`
+}
+
+type BankDetails struct {
+	AccountHolder string `json:"accountHolder" bson:"accountHolder"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// SupplierReport aggregates the purchases placed with a supplier.
+// The filled quantity ignores over-deliveries so that the fill rate never exceeds 1,
+// and the net spend is what remains once the credits of the returns are deducted.
+type SupplierReport struct {
+	SupplierID      primitive.ObjectID `json:"supplier" bson:"_id"`
+	SupplierName    string             `json:"supplierName" bson:"supplierName"`
+	Purchases       int                `json:"purchases"`
+	OrderedQuantity int                `json:"orderedQuantity" bson:"orderedQuantity"`
+	FilledQuantity  int                `json:"filledQuantity" bson:"filledQuantity"`
+	FillRate        float64            `json:"fillRate" bson:"fillRate"`
+	Spend           float64            `json:"spend"`
+	Credits         float64            `json:"credits"`
+	NetSpend        float64            `json:"netSpend" bson:"netSpend"`
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -8,15 +10,27 @@ import (
 type User struct {
    ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
    Email         string             `json:"email"`
-   Password      string             `json:"password"`
+   // Password is hashed with bcrypt when stored, and never serialised to JSON
+   Password      string             `json:"-"`
    FirstName     string             `json:"first_name"`
    LastName      string             `json:"last_name"`
    Role          string             `json:"role"`
-   *Claims
+   SupplierID    primitive.ObjectID `json:"supplier" bson:"supplier,omitempty"`
+   TenantID      primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+   DeletedAt     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+   DeletedBy     primitive.ObjectID `json:"deletedBy" bson:"deletedBy,omitempty"`
+   Version       int                `json:"version" bson:"version"`
+   // SessionVersion is carried by the tokens of the user, and incremented to revoke them when the password changes
+   SessionVersion int               `json:"-" bson:"sessionVersion"`
+   *Claims                          `json:"-"`
 }
 
 type Claims struct {
    UserID   primitive.ObjectID      `json:"userID"`
    Role string                      `json:"role" bson:"omitempty"`
+   SupplierID primitive.ObjectID    `json:"supplierID" bson:"supplierID,omitempty"`
+   TenantID primitive.ObjectID      `json:"tenantID" bson:"tenantID,omitempty"`
+   SessionVersion int               `json:"sessionVersion,omitempty" bson:"-"`
+
    jwt.RegisteredClaims
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Version is the state of an entity after one of its writes, numbered after the version field of the entity.
+type Version struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID `json:"tenant" bson:"tenantId,omitempty"`
+	Entity    string             `json:"entity"`
+	EntityID  primitive.ObjectID `json:"entityId" bson:"entityId"`
+	Number    int                `json:"number"`
+	Action    string             `json:"action"`
+	ActorID   primitive.ObjectID `json:"actor" bson:"actor,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Document  interface{}        `json:"document" bson:"-"`
+}
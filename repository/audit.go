@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// auditCollection is the append-only collection the writes are recorded in.
+const auditCollection = "audit"
+
+// redactedFields are recorded as changed in the audit log, without their values.
+var redactedFields = map[string]bool{"password": true}
+
+// snapshot returns the documents a write is about to change, so that the changes can be audited.
+// The filter must already be restricted to the scope.
+func (c *scopedCollection) snapshot(ctx context.Context, filter interface{}, many bool) ([]bson.M, error) {
+	opts := options.Find()
+	if !many {
+		opts.SetLimit(1)
+	}
+	cursor, err := c.collection.Find(c.bind(ctx), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var documents []bson.M
+	err = cursor.All(ctx, &documents)
+	return documents, err
+}
+
+func (c *scopedCollection) findByID(ctx context.Context, id interface{}) (bson.M, error) {
+	var document bson.M
+	if err := c.collection.FindOne(c.bind(ctx), bson.M{"_id": id}).Decode(&document); err != nil {
+		return nil, fmt.Errorf("audit snapshot of %s %v failed: %w", c.collection.Name(), id, err)
+	}
+	return document, nil
+}
+
+// recordUpdates records the changes made to the documents of the snapshot, and the upserted document if any.
+// The changed names are propagated to the documents holding a copy of them.
+func (c *scopedCollection) recordUpdates(ctx context.Context, action string, before []bson.M, upsertedID interface{}) error {
+	for _, document := range before {
+		after, err := c.findByID(ctx, document["_id"])
+		if err != nil {
+			return err
+		}
+		if err := c.record(ctx, action, document, after); err != nil {
+			return err
+		}
+		c.propagateNames(ctx, document, after)
+	}
+	if upsertedID != nil {
+		after, err := c.findByID(ctx, upsertedID)
+		if err != nil {
+			return err
+		}
+		return c.record(ctx, model.AuditActionCreate, nil, after)
+	}
+	return nil
+}
+
+// record appends an entry to the audit log, and a version of the document when its entity is versioned.
+// A failure fails the write, which is rolled back when it is part of a unit of work.
+func (c *scopedCollection) record(ctx context.Context, action string, before bson.M, after bson.M) error {
+	document := after
+	if document == nil {
+		document = before
+	}
+	if document == nil {
+		return nil
+	}
+	changes := diff(before, after)
+	if action == model.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	entry := model.AuditEntry{
+		TenantID:  c.scope.TenantID,
+		ActorID:   c.scope.ActorID,
+		Action:    action,
+		Entity:    c.collection.Name(),
+		EntityID:  document["_id"],
+		Changes:   changes,
+		Timestamp: time.Now(),
+		RequestID: c.scope.RequestID,
+		IP:        c.scope.IP,
+	}
+	if tenantID, ok := document[tenantField].(primitive.ObjectID); ok {
+		entry.TenantID = tenantID
+	}
+	_, err := c.collection.Database().Collection(auditCollection).InsertOne(c.bind(ctx), entry)
+	if err != nil {
+		return fmt.Errorf("audit of %s %v failed: %w", entry.Entity, entry.EntityID, err)
+	}
+	if after != nil && versionedCollections[entry.Entity] {
+		return c.recordVersion(ctx, entry, after)
+	}
+	return nil
+}
+
+// diff returns the top-level fields which differ between two versions of a document, besides their version.
+func diff(before bson.M, after bson.M) map[string]model.AuditChange {
+	changes := map[string]model.AuditChange{}
+	for key, value := range before {
+		if !reflect.DeepEqual(value, after[key]) {
+			changes[key] = model.AuditChange{Before: value, After: after[key]}
+		}
+	}
+	for key, value := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = model.AuditChange{After: value}
+		}
+	}
+	delete(changes, "_id")
+	delete(changes, versionField)
+	delete(changes, lockField)
+	for key := range changes {
+		if redactedFields[key] {
+			changes[key] = model.AuditChange{}
+		}
+	}
+	return changes
+}
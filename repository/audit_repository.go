@@ -0,0 +1,8 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type AuditRepository interface {
+	List(entity string, entityID string, actorID string) ([]model.AuditEntry, error)
+	WithScope(scope Scope) AuditRepository
+}
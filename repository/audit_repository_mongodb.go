@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditMongoRepository is a concrete implementation of AuditRepository using MongoDB.
+// The entries are written by the scoped collections of the other repositories; this one only reads them.
+type AuditMongoRepository struct {
+	auditCollection *scopedCollection
+}
+
+func NewAuditMongoRepository(db *mongo.Database) *AuditMongoRepository {
+	return &AuditMongoRepository{
+		auditCollection: newScopedCollection(db.Collection(auditCollection)),
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *AuditMongoRepository) WithScope(scope Scope) AuditRepository {
+	scoped := *r
+	scoped.auditCollection = r.auditCollection.withScope(scope)
+	return &scoped
+}
+
+// List retrieves the audit entries, most recent first. Every criterion is optional.
+func (r *AuditMongoRepository) List(entity string, entityID string, actorID string) ([]model.AuditEntry, error) {
+	filter := bson.M{}
+	if entity != "" {
+		filter["entity"] = entity
+	}
+	if entityID != "" {
+		objectID, err := primitive.ObjectIDFromHex(entityID)
+		if err != nil {
+			return nil, invalid("audit query", "entityId", "is not a valid ID")
+		}
+		filter["entityId"] = objectID
+	}
+	if actorID != "" {
+		objectID, err := primitive.ObjectIDFromHex(actorID)
+		if err != nil {
+			return nil, invalid("audit query", "actor", "is not a valid ID")
+		}
+		filter["actor"] = objectID
+	}
+
+	var entries []model.AuditEntry
+	cursor, err := r.auditCollection.Find(context.Background(), filter, options.Find().SetSort(bson.M{"timestamp": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+// bulkOperation applies one operation of a bulk request through repositories given the scope,
+// and returns the ID of the document it wrote.
+type bulkOperation func(scope Scope, index int) (string, error)
+
+// failedOperation stops an atomic bulk request, rolling its transaction back.
+type failedOperation struct {
+	index int
+	err   error
+}
+
+func (e *failedOperation) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap lets the transaction be retried when the operation failed for a transient reason.
+func (e *failedOperation) Unwrap() error {
+	return e.err
+}
+
+// bulkWrite applies count operations in order and reports the outcome of each. Without atomic, a failed
+// operation does not prevent the next ones. With atomic, the operations run in a transaction which
+// is rolled back as soon as one of them fails.
+func (c *scopedCollection) bulkWrite(count int, atomic bool, apply bulkOperation) ([]model.BulkResult, error) {
+	results := make([]model.BulkResult, count)
+	for i := range results {
+		results[i] = model.BulkResult{Index: i, Status: model.BulkStatusSkipped}
+	}
+	if !atomic {
+		for i := range results {
+			results[i].ID, results[i].Status, results[i].Error = outcome(apply(c.scope, i))
+		}
+		return results, nil
+	}
+
+	err := c.inTransaction(func(scope Scope) error {
+		for i := range results {
+			id, err := apply(scope, i)
+			if err != nil {
+				return &failedOperation{index: i, err: err}
+			}
+			results[i].ID, results[i].Status = id, model.BulkStatusDone
+		}
+		return nil
+	})
+	var failed *failedOperation
+	if !errors.As(err, &failed) {
+		return results, err
+	}
+	for i := 0; i < failed.index; i++ {
+		results[i].Status = model.BulkStatusRolledBack
+	}
+	results[failed.index].Status, results[failed.index].Error = model.BulkStatusFailed, failed.err.Error()
+	return results, nil
+}
+
+func outcome(id string, err error) (string, string, string) {
+	if err != nil {
+		return id, model.BulkStatusFailed, err.Error()
+	}
+	return id, model.BulkStatusDone, ""
+}
+
+// errInvalidBulkAction is returned for the operations of a bulk request with an unknown action.
+var errInvalidBulkAction = invalid("bulk operation", "action", "must be create, update or delete")
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+type ContractRepository interface {
+	CreateContract(contract *model.Contract) error
+	GetContractByID(id string) (*model.Contract, error)
+	UpdateContract(id string, updatedContract *model.Contract, version int) error
+	DeleteContract(id string, version int) error
+	RestoreContract(id string) error
+	ListBySupplier(supplierID string) ([]model.Contract, error)
+	ListExpiring(within time.Duration) ([]model.Contract, error)
+	WithScope(scope Scope) ContractRepository
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ContractMongoRepository is a concrete implementation of ContractRepository using MongoDB.
+type ContractMongoRepository struct {
+	contractsCollection *scopedCollection
+	suppliersCollection *scopedCollection
+	locationsCollection *scopedCollection
+}
+
+func NewContractMongoRepository(db *mongo.Database) *ContractMongoRepository {
+	return &ContractMongoRepository{
+		contractsCollection: newScopedCollection(db.Collection("contracts")),
+		suppliersCollection: newScopedCollection(db.Collection("suppliers")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *ContractMongoRepository) WithScope(scope Scope) ContractRepository {
+	scoped := *r
+	scoped.contractsCollection = r.contractsCollection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *ContractMongoRepository) unitOfWork(fn func(r *ContractMongoRepository) error) error {
+	return r.contractsCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*ContractMongoRepository))
+	})
+}
+
+// CreateContract adds a new contract to the database.
+func (r *ContractMongoRepository) CreateContract(contract *model.Contract) error {
+	return r.unitOfWork(func(r *ContractMongoRepository) error {
+		return r.createContract(contract)
+	})
+}
+
+func (r *ContractMongoRepository) createContract(contract *model.Contract) error {
+	if err := r.validateContract(primitive.NilObjectID, contract); err != nil {
+		return err
+	}
+	result, err := r.contractsCollection.InsertOne(context.Background(), contract)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	contract.ID = insertedID
+	return nil
+}
+
+// GetContractByID retrieves a contract by ID from the database.
+func (r *ContractMongoRepository) GetContractByID(id string) (*model.Contract, error) {
+	objectID, err := parseID("contract", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var contract model.Contract
+	err = r.contractsCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&contract)
+	if err != nil {
+		return nil, notFound(err, "contract", id)
+	}
+	return &contract, nil
+}
+
+// UpdateContract updates an existing contract in the database.
+func (r *ContractMongoRepository) UpdateContract(id string, updatedContract *model.Contract, version int) error {
+	return r.unitOfWork(func(r *ContractMongoRepository) error {
+		return r.updateContract(id, updatedContract, version)
+	})
+}
+
+func (r *ContractMongoRepository) updateContract(id string, updatedContract *model.Contract, version int) error {
+	objectID, err := parseID("contract", id)
+	if err != nil {
+		return err
+	}
+	if err := r.validateContract(objectID, updatedContract); err != nil {
+		return err
+	}
+
+	_, err = r.contractsCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedContract})
+	if err != nil {
+		return err
+	}
+	updatedContract.ID = objectID
+	updatedContract.Version = version + 1
+	return nil
+}
+
+// DeleteContract removes a contract from the database by ID.
+// A contract purchases were made under cannot be deleted.
+func (r *ContractMongoRepository) DeleteContract(id string, version int) error {
+	objectID, err := parseID("contract", id)
+	if err != nil {
+		return err
+	}
+
+	return r.contractsCollection.deleteByID(context.Background(), objectID, version)
+}
+
+// RestoreContract brings back a deleted contract, as long as it does not overlap a contract made since.
+func (r *ContractMongoRepository) RestoreContract(id string) error {
+	return r.unitOfWork(func(r *ContractMongoRepository) error {
+		return r.restoreContract(id)
+	})
+}
+
+func (r *ContractMongoRepository) restoreContract(id string) error {
+	objectID, err := parseID("contract", id)
+	if err != nil {
+		return err
+	}
+	var contract model.Contract
+	err = r.contractsCollection.withDeleted().FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&contract)
+	if err != nil {
+		return notFound(err, "contract", id)
+	}
+	if err := r.validateContract(objectID, &contract); err != nil {
+		return err
+	}
+	return r.contractsCollection.restoreByID(id)
+}
+
+// ListBySupplier retrieves the contracts of a supplier, most recent first.
+func (r *ContractMongoRepository) ListBySupplier(id string) ([]model.Contract, error) {
+	supplierID, err := parseID("supplier", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.find(bson.M{"supplier": supplierID}, options.Find().SetSort(bson.M{"startDate": -1}))
+}
+
+// ListExpiring retrieves the active contracts ending within the given duration, closest end first.
+func (r *ContractMongoRepository) ListExpiring(within time.Duration) ([]model.Contract, error) {
+	now := time.Now()
+	filter := bson.M{"startDate": bson.M{"$lte": now}, "endDate": bson.M{"$gte": now, "$lte": now.Add(within)}}
+	return r.find(filter, options.Find().SetSort(bson.M{"endDate": 1}))
+}
+
+func (r *ContractMongoRepository) find(filter bson.M, opts *options.FindOptions) ([]model.Contract, error) {
+	var contracts []model.Contract
+	cursor, err := r.contractsCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &contracts)
+	if err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
+
+// validateContract checks the terms of a contract, that its prices belong to locations of the supplier
+// and that it does not overlap another contract of the same supplier.
+func (r *ContractMongoRepository) validateContract(id primitive.ObjectID, contract *model.Contract) error {
+	if contract.StartDate.IsZero() {
+		return invalid("contract", "startDate", "is required")
+	}
+	if contract.EndDate.IsZero() || !contract.EndDate.After(contract.StartDate) {
+		return invalid("contract", "endDate", "must be after startDate")
+	}
+	if contract.MinimumOrderQuantity < 0 {
+		return invalid("contract", "minimumOrderQuantity", "must not be negative")
+	}
+
+	var supplier model.Supplier
+	err := r.suppliersCollection.FindOne(context.Background(), bson.M{"_id": contract.SupplierID}).Decode(&supplier)
+	if err == mongo.ErrNoDocuments {
+		return &NotFoundError{Entity: "supplier", ID: contract.SupplierID.Hex()}
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.suppliersCollection.lock(context.Background(), supplier.ID); err != nil {
+		return err
+	}
+	contract.TenantID = supplier.TenantID
+	// Contracts fall back on the default payment terms of the supplier
+	if contract.PaymentTerms == "" {
+		contract.PaymentTerms = supplier.PaymentTerms
+	}
+
+	for i, price := range contract.Prices {
+		field := fmt.Sprintf("prices[%d]", i)
+		if price.Price < 0 {
+			return invalid("contract", field+".price", "must not be negative")
+		}
+		count, err := r.locationsCollection.CountDocuments(context.Background(), bson.M{"_id": price.LocationID, "supplier": contract.SupplierID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return invalid("contract", field+".location", "is not a location of the supplier")
+		}
+	}
+
+	overlapping := bson.M{
+		"_id":       bson.M{"$ne": id},
+		"supplier":  contract.SupplierID,
+		"startDate": bson.M{"$lte": contract.EndDate},
+		"endDate":   bson.M{"$gte": contract.StartDate},
+	}
+	count, err := r.contractsCollection.CountDocuments(context.Background(), overlapping)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return conflict("the contract overlaps another contract of the supplier")
+	}
+	return nil
+}
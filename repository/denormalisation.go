@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// denormalisation is a copy of a field of the referenced documents, kept in the documents referring to them
+// so that they can be listed without joins. nameField of the documents of collection referring through field
+// holds the nameSource field of the referenced document.
+type denormalisation struct {
+	collection string
+	field      string
+	nameField  string
+	nameSource string
+}
+
+// denormalisations lists, by referenced collection, the copies of its fields.
+var denormalisations = map[string][]denormalisation{
+	"suppliers": {
+		{collection: "locations", field: "supplier", nameField: "supplierName", nameSource: "name"},
+		{collection: "purchases", field: "supplier", nameField: "supplierName", nameSource: "name"},
+	},
+	"locations": {
+		{collection: "purchases", field: "location", nameField: "locationName", nameSource: "name"},
+	},
+	"users": {
+		{collection: "purchases", field: "user", nameField: "userName", nameSource: "email"},
+		{collection: "receipts", field: "receiver", nameField: "receiverName", nameSource: "email"},
+		{collection: "returns", field: "user", nameField: "userName", nameSource: "email"},
+	},
+}
+
+// denormalisationOf returns the copy kept by the documents referring through the relation, if any.
+func denormalisationOf(referenced string, relation relation) (denormalisation, bool) {
+	for _, denormalisation := range denormalisations[referenced] {
+		if denormalisation.collection == relation.collection && denormalisation.field == relation.field {
+			return denormalisation, true
+		}
+	}
+	return denormalisation{}, false
+}
+
+// propagateNames copies the fields of a document which changed to the documents referring to it.
+// A failure leaves stale copies behind, which the repair command fixes, so it is only logged.
+func (c *scopedCollection) propagateNames(ctx context.Context, before bson.M, after bson.M) {
+	if after == nil {
+		return
+	}
+	for _, denormalisation := range denormalisations[c.collection.Name()] {
+		if reflect.DeepEqual(before[denormalisation.nameSource], after[denormalisation.nameSource]) {
+			continue
+		}
+		_, err := c.sibling(denormalisation.collection).withDeleted().copyName(ctx, denormalisation, after)
+		if err != nil {
+			log.Printf("Propagation of %s %v to %s failed: %v\n", c.collection.Name(), after["_id"], denormalisation.collection, err)
+		}
+	}
+}
+
+// copyName updates the copies of the name of the referenced document which differ from it. The copies are
+// not changes of the documents holding them: they are written as is, neither audited nor versioned, and leave
+// the version of the documents, which their clients hold as ETags, unchanged.
+func (c *scopedCollection) copyName(ctx context.Context, denormalisation denormalisation, referenced bson.M) (int64, error) {
+	name := referenced[denormalisation.nameSource]
+	filter := bson.M{denormalisation.field: referenced["_id"], denormalisation.nameField: bson.M{"$ne": name}}
+	result, err := c.collection.UpdateMany(c.bind(ctx), c.filter(filter), bson.M{"$set": bson.M{denormalisation.nameField: name}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// repairNames rescans the documents of the collection and fixes the copies of their fields which
+// are out of date, and returns how many documents were fixed.
+func (c *scopedCollection) repairNames(ctx context.Context) (int64, error) {
+	cursor, err := c.withDeleted().Find(ctx, bson.M{}, options.Find().SetProjection(nameSources(c.collection.Name())))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var repaired int64
+	for cursor.Next(ctx) {
+		var referenced bson.M
+		if err := cursor.Decode(&referenced); err != nil {
+			return repaired, err
+		}
+		for _, denormalisation := range denormalisations[c.collection.Name()] {
+			fixed, err := c.sibling(denormalisation.collection).withDeleted().copyName(ctx, denormalisation, referenced)
+			if err != nil {
+				return repaired, err
+			}
+			repaired += fixed
+		}
+	}
+	return repaired, cursor.Err()
+}
+
+func nameSources(collection string) bson.M {
+	projection := bson.M{"_id": 1}
+	for _, denormalisation := range denormalisations[collection] {
+		projection[denormalisation.nameSource] = 1
+	}
+	return projection
+}
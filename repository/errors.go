@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotFoundError is returned when a record does not exist, or not in the scope of the repository.
+type NotFoundError struct {
+	Entity string
+	ID     string
+	// Message replaces the default message, for the records not found by ID
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s with ID %s does not exist", e.Entity, e.ID)
+}
+
+// ValidationError is returned when the input of a write is invalid, with the reason for every field at fault.
+type ValidationError struct {
+	Entity string
+	Fields []model.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var fields []string
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+	return fmt.Sprintf("Error when validating %s input: %s", e.Entity, strings.Join(fields, ", "))
+}
+
+// invalid returns a ValidationError for a single field.
+func invalid(entity string, field string, message string) error {
+	return &ValidationError{Entity: entity, Fields: []model.FieldError{{Field: field, Message: message}}}
+}
+
+// ConflictError is returned when a write is refused because of the state of the records it involves.
+// When a delete is refused because other documents still refer to the document, Blockers lists them.
+type ConflictError struct {
+	Entity   string
+	ID       primitive.ObjectID
+	Message  string
+	Blockers []Blocker
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Blockers) == 0 {
+		return e.Message
+	}
+	var blockers []string
+	for _, blocker := range e.Blockers {
+		blockers = append(blockers, fmt.Sprintf("%d %s", len(blocker.IDs), blocker.Entity))
+	}
+	return fmt.Sprintf("%s with ID %s is still referenced by %s", e.Entity, e.ID.Hex(), strings.Join(blockers, ", "))
+}
+
+// conflict returns a ConflictError with a formatted message.
+func conflict(format string, args ...interface{}) error {
+	return &ConflictError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ForbiddenError is returned when the actor is not allowed to make a write, whatever its input.
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// parseID parses the ID of a record: a malformed ID cannot match any record.
+func parseID(entity string, id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, &NotFoundError{Entity: entity, ID: id}
+	}
+	return objectID, nil
+}
+
+// notFound turns the error of the lookup of a missing record into a NotFoundError.
+func notFound(err error, entity string, id string) error {
+	if err == mongo.ErrNoDocuments {
+		return &NotFoundError{Entity: entity, ID: id}
+	}
+	return err
+}
+
+// entityOf returns the entity stored in a collection, named like in the errors.
+func entityOf(collection string) string {
+	return strings.TrimSuffix(collection, "s")
+}
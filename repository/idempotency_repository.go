@@ -0,0 +1,9 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type IdempotencyRepository interface {
+	Reserve(key string, fingerprint string) (*model.IdempotentRequest, error)
+	Complete(key string, statusCode int, contentType string, body []byte) error
+	Release(key string) error
+}
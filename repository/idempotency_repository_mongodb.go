@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyMongoRepository is a concrete implementation of IdempotencyRepository using MongoDB.
+// The keys of completed requests are kept for the window, after which MongoDB removes them. The keys
+// of requests in flight are only held for the lease, so that a request never completed nor released,
+// such as one cut short by a crash, does not hold its key for the whole window.
+type IdempotencyMongoRepository struct {
+	collection *mongo.Collection
+	window     time.Duration
+	lease      time.Duration
+}
+
+func NewIdempotencyMongoRepository(db *mongo.Database, window time.Duration, lease time.Duration) *IdempotencyMongoRepository {
+	r := &IdempotencyMongoRepository{
+		collection: db.Collection("idempotencyKeys"),
+		window:     window,
+		lease:      lease,
+	}
+	_, err := r.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Printf("Creating the expiry index of the idempotency keys failed: %v\n", err)
+	}
+	return r
+}
+
+// Reserve marks the key as in flight for a request with the given fingerprint, for the lease. It returns
+// nil when the key was free, expired or left in flight past its lease, and the request already made with
+// the key otherwise.
+func (r *IdempotencyMongoRepository) Reserve(key string, fingerprint string) (*model.IdempotentRequest, error) {
+	now := time.Now()
+	request := model.IdempotentRequest{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      model.IdempotencyStatusInFlight,
+		CreatedAt:   now,
+		LockedUntil: now.Add(r.lease),
+		ExpiresAt:   now.Add(r.lease),
+	}
+	_, err := r.collection.InsertOne(context.Background(), request)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// The expired keys are only removed every minute or so
+	result, err := r.collection.ReplaceOne(context.Background(), bson.M{
+		"_id": key,
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+			bson.M{"status": model.IdempotencyStatusInFlight, "lockedUntil": bson.M{"$lte": now}},
+		},
+	}, request)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount > 0 {
+		return nil, nil
+	}
+	var previous model.IdempotentRequest
+	err = r.collection.FindOne(context.Background(), bson.M{"_id": key}).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return r.Reserve(key, fingerprint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// Complete stores the response to the request made with the key, for its retries to replay it
+// within the window.
+func (r *IdempotencyMongoRepository) Complete(key string, statusCode int, contentType string, body []byte) error {
+	_, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": key}, bson.M{
+		"$set": bson.M{
+			"status":      model.IdempotencyStatusCompleted,
+			"statusCode":  statusCode,
+			"contentType": contentType,
+			"body":        body,
+			"expiresAt":   time.Now().Add(r.window),
+		},
+		"$unset": bson.M{"lockedUntil": ""},
+	})
+	return err
+}
+
+// Release frees the key of a request which failed, so that it can be retried.
+func (r *IdempotencyMongoRepository) Release(key string) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": key})
+	return err
+}
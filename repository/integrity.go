@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Policies applied to the documents referring to a deleted document.
+const (
+	// policyRestrict refuses the delete while referring documents exist.
+	policyRestrict = "restrict"
+	// policyCascade deletes the referring documents along with the referenced one.
+	policyCascade = "cascade"
+	// policyNullify clears the reference once the deleted document is purged, keeping its name in the referring
+	// documents. The reference is kept while the document is only soft deleted, for a restore to find it back.
+	policyNullify = "nullify"
+)
+
+// relation is a reference held by the documents of a collection.
+// Nullified references keep the denormalised name of the deleted document, if they have one.
+type relation struct {
+	collection string
+	field      string
+	policy     string
+}
+
+// relations lists, by referenced collection, the references to its documents.
+var relations = map[string][]relation{
+	"organisations": {
+		{collection: "users", field: tenantField, policy: policyRestrict},
+		{collection: "suppliers", field: tenantField, policy: policyRestrict},
+	},
+	"suppliers": {
+		{collection: "users", field: "supplier", policy: policyRestrict},
+		{collection: "invoices", field: "supplier", policy: policyRestrict},
+		{collection: "rfqs", field: "invitations.supplier", policy: policyRestrict},
+		{collection: "locations", field: "supplier", policy: policyCascade},
+		{collection: "contracts", field: "supplier", policy: policyCascade},
+	},
+	"locations": {
+		{collection: "purchases", field: "location", policy: policyRestrict},
+		{collection: "priceChanges", field: "location", policy: policyCascade},
+	},
+	"contracts": {
+		{collection: "purchases", field: "contract", policy: policyRestrict},
+	},
+	"purchases": {
+		{collection: "receipts", field: "purchase", policy: policyRestrict},
+		{collection: "returns", field: "purchase", policy: policyRestrict},
+		{collection: "invoices", field: "lines.purchase", policy: policyRestrict},
+	},
+	"users": {
+		{collection: "purchases", field: "user", policy: policyNullify},
+		{collection: "receipts", field: "receiver", policy: policyNullify},
+		{collection: "returns", field: "user", policy: policyNullify},
+		{collection: "priceChanges", field: "requestedBy", policy: policyNullify},
+	},
+}
+
+// Blocker lists the documents of a collection which prevent a delete.
+type Blocker struct {
+	Entity string               `json:"entity"`
+	Field  string               `json:"field"`
+	IDs    []primitive.ObjectID `json:"ids"`
+}
+
+// sibling returns another collection of the database, in the same scope.
+func (c *scopedCollection) sibling(name string) *scopedCollection {
+	return &scopedCollection{collection: c.collection.Database().Collection(name), scope: c.scope}
+}
+
+// deleteByID deletes a document at the given version, applying the policies of the relations referring to it.
+// Nothing is deleted when a restricted relation, of the document or of a document it cascades to, is in use.
+// The checks and the deletes run as one unit of work, so that no reference is added in between.
+func (c *scopedCollection) deleteByID(ctx context.Context, id primitive.ObjectID, version int) error {
+	return c.inUnitOfWork(func(scope Scope) error {
+		c := c.withScope(scope)
+		conditional := c.ifVersion(version)
+		found, err := conditional.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if found == 0 {
+			return conditional.versionMismatch(ctx, bson.M{"_id": id})
+		}
+
+		blockers, err := c.blockers(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			return &ConflictError{Entity: c.collection.Name(), ID: id, Blockers: blockers}
+		}
+		return conditional.deleteCascading(ctx, id)
+	})
+}
+
+func (c *scopedCollection) blockers(ctx context.Context, id primitive.ObjectID) ([]Blocker, error) {
+	var blockers []Blocker
+	for _, relation := range relations[c.collection.Name()] {
+		if relation.policy == policyNullify {
+			continue
+		}
+		dependents := c.sibling(relation.collection)
+		ids, err := dependents.findIDs(ctx, bson.M{relation.field: id})
+		if err != nil {
+			return nil, err
+		}
+		if relation.policy == policyRestrict {
+			if len(ids) > 0 {
+				blockers = append(blockers, Blocker{Entity: relation.collection, Field: relation.field, IDs: ids})
+			}
+			continue
+		}
+		for _, dependentID := range ids {
+			dependentBlockers, err := dependents.blockers(ctx, dependentID)
+			if err != nil {
+				return nil, err
+			}
+			blockers = append(blockers, dependentBlockers...)
+		}
+	}
+	return blockers, nil
+}
+
+// deleteCascading deletes a document, then the documents it cascades to.
+// The document is deleted first, so that the documents deleted with it are the ones deleted since.
+func (c *scopedCollection) deleteCascading(ctx context.Context, id primitive.ObjectID) error {
+	var document bson.M
+	err := c.FindOne(ctx, bson.M{"_id": id}).Decode(&document)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := c.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+
+	for _, relation := range relations[c.collection.Name()] {
+		if relation.policy != policyCascade {
+			continue
+		}
+		dependents := c.sibling(relation.collection)
+		ids, err := dependents.findIDs(ctx, bson.M{relation.field: id})
+		if err != nil {
+			return err
+		}
+		for _, dependentID := range ids {
+			if err := dependents.deleteCascading(ctx, dependentID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nullifyReferences clears the nullified references to a purged document, deleted or not, keeping its name
+// in the referring documents.
+func (c *scopedCollection) nullifyReferences(ctx context.Context, document bson.M) error {
+	for _, relation := range relations[c.collection.Name()] {
+		if relation.policy != policyNullify {
+			continue
+		}
+		set := bson.M{relation.field: nil}
+		if denormalisation, ok := denormalisationOf(c.collection.Name(), relation); ok {
+			set[denormalisation.nameField] = document[denormalisation.nameSource]
+		}
+		dependents := c.sibling(relation.collection).withDeleted()
+		if _, err := dependents.UpdateMany(ctx, bson.M{relation.field: document["_id"]}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCascading restores a deleted document along with the documents deleted with it by cascade.
+func (c *scopedCollection) restoreCascading(ctx context.Context, id primitive.ObjectID, deletedSince time.Time) error {
+	if _, err := c.Restore(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+	for _, relation := range relations[c.collection.Name()] {
+		if relation.policy != policyCascade {
+			continue
+		}
+		dependents := c.sibling(relation.collection)
+		ids, err := dependents.withDeleted().findIDs(ctx, bson.M{relation.field: id, deletedAtField: bson.M{"$gte": deletedSince}})
+		if err != nil {
+			return err
+		}
+		for _, dependentID := range ids {
+			if err := dependents.restoreCascading(ctx, dependentID, deletedSince); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *scopedCollection) findIDs(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := c.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.ID)
+	}
+	return ids, nil
+}
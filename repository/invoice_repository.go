@@ -0,0 +1,11 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type InvoiceRepository interface {
+	CreateInvoice(invoice *model.Invoice) error
+	GetInvoiceByID(id string) (*model.Invoice, error)
+	ListByStatus(status string) ([]model.Invoice, error)
+	ResolveInvoice(id string, status string, user string) error
+	WithScope(scope Scope) InvoiceRepository
+}
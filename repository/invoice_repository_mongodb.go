@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvoiceMongoRepository is a concrete implementation of InvoiceRepository using MongoDB.
+type InvoiceMongoRepository struct {
+	invoicesCollection  *scopedCollection
+	purchasesCollection *scopedCollection
+	locationsCollection *scopedCollection
+	// tolerance is the relative difference accepted between the invoice and the purchases (0.02 for 2%)
+	tolerance float64
+}
+
+func NewInvoiceMongoRepository(db *mongo.Database, tolerance float64) *InvoiceMongoRepository {
+	return &InvoiceMongoRepository{
+		invoicesCollection:  newScopedCollection(db.Collection("invoices")),
+		purchasesCollection: newScopedCollection(db.Collection("purchases")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+		tolerance:           tolerance,
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *InvoiceMongoRepository) WithScope(scope Scope) InvoiceRepository {
+	scoped := *r
+	scoped.invoicesCollection = r.invoicesCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *InvoiceMongoRepository) unitOfWork(fn func(r *InvoiceMongoRepository) error) error {
+	return r.invoicesCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*InvoiceMongoRepository))
+	})
+}
+
+// CreateInvoice records a supplier invoice after matching it against its purchases.
+// Matched invoices become payable, the others are kept as exceptions for an admin to resolve.
+func (r *InvoiceMongoRepository) CreateInvoice(invoice *model.Invoice) error {
+	return r.unitOfWork(func(r *InvoiceMongoRepository) error {
+		return r.createInvoice(invoice)
+	})
+}
+
+func (r *InvoiceMongoRepository) createInvoice(invoice *model.Invoice) error {
+	if len(strings.TrimSpace(invoice.Number)) == 0 {
+		return invalid("invoice", "number", "is required")
+	}
+	if len(invoice.Lines) == 0 {
+		return invalid("invoice", "lines", "needs at least one line")
+	}
+	count, err := r.invoicesCollection.CountDocuments(context.Background(), bson.M{"supplier": invoice.SupplierID, "number": invoice.Number})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return conflict("invoice %s was already recorded for this supplier", invoice.Number)
+	}
+
+	discrepancies, err := r.match(invoice)
+	if err != nil {
+		return err
+	}
+	invoice.Discrepancies = discrepancies
+	invoice.Status = model.InvoiceStatusPayable
+	if len(discrepancies) > 0 {
+		invoice.Status = model.InvoiceStatusException
+	}
+
+	result, err := r.invoicesCollection.InsertOne(context.Background(), invoice)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	invoice.ID = insertedID
+	return nil
+}
+
+// GetInvoiceByID retrieves an invoice by ID from the database.
+func (r *InvoiceMongoRepository) GetInvoiceByID(id string) (*model.Invoice, error) {
+	objectID, err := parseID("invoice", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var invoice model.Invoice
+	err = r.invoicesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&invoice)
+	if err != nil {
+		return nil, notFound(err, "invoice", id)
+	}
+	return &invoice, nil
+}
+
+// ListByStatus retrieves the invoices with the given status, or all of them when status is empty.
+func (r *InvoiceMongoRepository) ListByStatus(status string) ([]model.Invoice, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	var invoices []model.Invoice
+	opts := options.Find().SetSort(bson.M{"date": -1})
+	cursor, err := r.invoicesCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &invoices)
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// ResolveInvoice settles an exception, either making the invoice payable or rejecting it.
+func (r *InvoiceMongoRepository) ResolveInvoice(id string, status string, user string) error {
+	objectID, err := parseID("invoice", id)
+	if err != nil {
+		return err
+	}
+	userID, err := parseID("user", user)
+	if err != nil {
+		return err
+	}
+	if status != model.InvoiceStatusPayable && status != model.InvoiceStatusRejected {
+		return invalid("invoice", "status", fmt.Sprintf("must be %s or %s", model.InvoiceStatusPayable, model.InvoiceStatusRejected))
+	}
+
+	result, err := r.invoicesCollection.UpdateOne(context.Background(),
+		bson.M{"_id": objectID, "status": model.InvoiceStatusException},
+		bson.M{"$set": bson.M{"status": status, "resolvedBy": userID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return conflict("invoice %s is not an exception", id)
+	}
+	return nil
+}
+
+// match performs the three-way matching of the invoice: every line is compared with the price
+// of its purchase and with the quantity received and not billed yet by the other invoices.
+// A purchase billed by several lines of the invoice is a discrepancy. It returns the discrepancies found.
+func (r *InvoiceMongoRepository) match(invoice *model.Invoice) ([]string, error) {
+	discrepancies := []string{}
+	linesTotal := 0.0
+
+	invoiced, err := r.invoicedQuantities(invoice.Lines)
+	if err != nil {
+		return nil, err
+	}
+	billed := map[primitive.ObjectID]bool{}
+	for _, line := range invoice.Lines {
+		linesTotal += line.Amount
+
+		if billed[line.PurchaseID] {
+			discrepancies = append(discrepancies, fmt.Sprintf("purchase %s is billed by several lines", line.PurchaseID.Hex()))
+			continue
+		}
+		billed[line.PurchaseID] = true
+
+		var purchase model.Purchase
+		err := r.purchasesCollection.FindOne(context.Background(), bson.M{"_id": line.PurchaseID}).Decode(&purchase)
+		if err == mongo.ErrNoDocuments {
+			discrepancies = append(discrepancies, fmt.Sprintf("purchase %s does not exist", line.PurchaseID.Hex()))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var location model.Location
+		err = r.locationsCollection.FindOne(context.Background(), bson.M{"_id": purchase.LocationID}).Decode(&location)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if location.SupplierID != invoice.SupplierID {
+			discrepancies = append(discrepancies, fmt.Sprintf("purchase %s was not placed with this supplier", line.PurchaseID.Hex()))
+			continue
+		}
+
+		uninvoiced := purchase.ReceivedQuantity - purchase.ReturnedQuantity - invoiced[line.PurchaseID]
+		if r.exceedsTolerance(float64(line.Quantity), float64(uninvoiced)) {
+			discrepancies = append(discrepancies, fmt.Sprintf("purchase %s: %d units invoiced but %d received and not invoiced yet", line.PurchaseID.Hex(), line.Quantity, uninvoiced))
+		}
+
+		expected := float64(line.Quantity) * purchase.UnitCredit()
+		if r.exceedsTolerance(line.Amount, expected) {
+			discrepancies = append(discrepancies, fmt.Sprintf("purchase %s: %.2f invoiced but %.2f expected", line.PurchaseID.Hex(), line.Amount, expected))
+		}
+	}
+
+	if invoice.Total == 0 {
+		invoice.Total = linesTotal
+	} else if r.exceedsTolerance(invoice.Total, linesTotal) {
+		discrepancies = append(discrepancies, fmt.Sprintf("invoice total %.2f does not match its lines (%.2f)", invoice.Total, linesTotal))
+	}
+	return discrepancies, nil
+}
+
+// invoicedQuantities sums, by purchase, the quantities the invoices which were not rejected already bill
+// for the purchases of the lines.
+func (r *InvoiceMongoRepository) invoicedQuantities(lines []model.InvoiceLine) (map[primitive.ObjectID]int, error) {
+	purchaseIDs := bson.A{}
+	for _, line := range lines {
+		purchaseIDs = append(purchaseIDs, line.PurchaseID)
+	}
+
+	cursor, err := r.invoicesCollection.Aggregate(context.Background(), bson.A{
+		bson.M{"$match": bson.M{"status": bson.M{"$ne": model.InvoiceStatusRejected}, "lines.purchase": bson.M{"$in": purchaseIDs}}},
+		bson.M{"$unwind": "$lines"},
+		bson.M{"$match": bson.M{"lines.purchase": bson.M{"$in": purchaseIDs}}},
+		bson.M{"$group": bson.M{"_id": "$lines.purchase", "quantity": bson.M{"$sum": "$lines.quantity"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var totals []struct {
+		PurchaseID primitive.ObjectID `bson:"_id"`
+		Quantity   int                `bson:"quantity"`
+	}
+	if err := cursor.All(context.Background(), &totals); err != nil {
+		return nil, err
+	}
+	invoiced := map[primitive.ObjectID]int{}
+	for _, total := range totals {
+		invoiced[total.PurchaseID] = total.Quantity
+	}
+	return invoiced, nil
+}
+
+// exceedsTolerance reports whether actual differs from expected by more than the configured tolerance.
+func (r *InvoiceMongoRepository) exceedsTolerance(actual float64, expected float64) bool {
+	return math.Abs(actual-expected) > r.tolerance*math.Abs(expected)
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/sandlayth/supplier-api/model"
 )
 
@@ -8,9 +10,20 @@ type LocationRepository interface {
 	//GetAllLocationsForLocation(supplierID string) ([]model.Location, error)
 	CreateLocation(supplier *model.Location) error
 	GetLocationByID(id string) (*model.Location, error)
-	UpdateLocation(id string, updatedLocation *model.Location) error
-	DeleteLocation(id string) error
+	UpdateLocation(id string, updatedLocation *model.Location, version int) error
+	DeleteLocation(id string, version int) error
+	RestoreLocation(id string) error
+	ListLocationVersions(id string) ([]model.Version, error)
+	GetLocationAsOf(id string, asOf time.Time) (*model.Location, error)
+	RevertLocation(id string, number int, version int) error
+	BulkWrite(operations []model.LocationOperation, atomic bool) ([]model.BulkResult, error)
+	AdjustPrices(supplierID string, percentage float64) (int64, error)
 	ListAll() ([]model.Location, error)
 	ListBySupplier(supplierID string) ([]model.Location, error)
+	ListBelowReorderPoint() ([]model.Location, error)
+	RequestPriceChange(id string, price float64, user string) (*model.PriceChange, error)
+	ListPriceChanges(status string) ([]model.PriceChange, error)
+	ReviewPriceChange(id string, approved bool, user string) error
 
+	WithScope(scope Scope) LocationRepository
 }
\ No newline at end of file
@@ -2,39 +2,74 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 
 	"github.com/sandlayth/supplier-api/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type LocationMongoRepository struct {
-	locationsCollection *mongo.Collection
-	suppliersCollection *mongo.Collection
+	locationsCollection    *scopedCollection
+	suppliersCollection    *scopedCollection
+	priceChangesCollection *scopedCollection
 }
 
 func NewLocationMongoRepository(db *mongo.Database) *LocationMongoRepository {
 	return &LocationMongoRepository{
-		locationsCollection: db.Collection("locations"),
-		suppliersCollection: db.Collection("suppliers"),
+		locationsCollection:    newScopedCollection(db.Collection("locations")),
+		suppliersCollection:    newScopedCollection(db.Collection("suppliers")),
+		priceChangesCollection: newScopedCollection(db.Collection("priceChanges")),
 	}
 }
 
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *LocationMongoRepository) WithScope(scope Scope) LocationRepository {
+	scoped := *r
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.priceChangesCollection = r.priceChangesCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *LocationMongoRepository) unitOfWork(fn func(r *LocationMongoRepository) error) error {
+	return r.locationsCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*LocationMongoRepository))
+	})
+}
+
 // CreateLocation adds a new location to the database.
 func (r *LocationMongoRepository) CreateLocation(location *model.Location) error {
-	err := r.supplierExists(location.SupplierID)
+	return r.unitOfWork(func(r *LocationMongoRepository) error {
+		return r.createLocation(location)
+	})
+}
+
+func (r *LocationMongoRepository) createLocation(location *model.Location) error {
+	supplier, err := r.getSupplier(location.SupplierID)
 	if err != nil {
 		return err
 	}
-	_, err = r.locationsCollection.InsertOne(context.Background(), location)
-	return err
+	location.SupplierName = supplier.Name
+	result, err := r.locationsCollection.InsertOne(context.Background(), location)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	location.ID = insertedID
+	return nil
 }
 
 // GetLocationByID retrieves a location by ID from the database.
 func (r *LocationMongoRepository) GetLocationByID(id string) (*model.Location, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID("location", id)
 	if err != nil {
 		return nil, err
 	}
@@ -42,35 +77,173 @@ func (r *LocationMongoRepository) GetLocationByID(id string) (*model.Location, e
 	var location model.Location
 	err = r.locationsCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&location)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "location", id)
 	}
 	return &location, nil
 }
 
 // UpdateLocation updates an existing location in the database.
-func (r *LocationMongoRepository) UpdateLocation(id string, updatedLocation *model.Location) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (r *LocationMongoRepository) UpdateLocation(id string, updatedLocation *model.Location, version int) error {
+	return r.unitOfWork(func(r *LocationMongoRepository) error {
+		return r.updateLocation(id, updatedLocation, version)
+	})
+}
+
+func (r *LocationMongoRepository) updateLocation(id string, updatedLocation *model.Location, version int) error {
+	objectID, err := parseID("location", id)
 	if err != nil {
 		return err
 	}
-	err = r.supplierExists(updatedLocation.SupplierID)
+	supplier, err := r.getSupplier(updatedLocation.SupplierID)
 	if err != nil {
 		return err
 	}
+	updatedLocation.SupplierName = supplier.Name
 
-	_, err = r.locationsCollection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedLocation})
+	_, err = r.locationsCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedLocation})
 	return err
 }
 
-// DeleteLocation removes a location from the database by ID.
-func (r *LocationMongoRepository) DeleteLocation(id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// DeleteLocation removes a location from the database by ID, along with its price change requests.
+// A location with purchases cannot be deleted.
+func (r *LocationMongoRepository) DeleteLocation(id string, version int) error {
+	objectID, err := parseID("location", id)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.locationsCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
-	return err
+	return r.locationsCollection.deleteByID(context.Background(), objectID, version)
+}
+
+// RestoreLocation brings back a deleted location, as long as its supplier is not deleted.
+func (r *LocationMongoRepository) RestoreLocation(id string) error {
+	return r.unitOfWork(func(r *LocationMongoRepository) error {
+		return r.restoreLocation(id)
+	})
+}
+
+func (r *LocationMongoRepository) restoreLocation(id string) error {
+	objectID, err := parseID("location", id)
+	if err != nil {
+		return err
+	}
+	var location model.Location
+	err = r.locationsCollection.withDeleted().FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&location)
+	if err != nil {
+		return notFound(err, "location", id)
+	}
+	if _, err := r.getSupplier(location.SupplierID); err != nil {
+		return err
+	}
+	return r.locationsCollection.restoreByID(id)
+}
+
+// ListLocationVersions retrieves the versions of a location, oldest first.
+func (r *LocationMongoRepository) ListLocationVersions(id string) ([]model.Version, error) {
+	objectID, err := parseID("location", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.locationsCollection.versions(context.Background(), objectID, func() interface{} { return &model.Location{} })
+}
+
+// GetLocationAsOf retrieves the state a location was in at the given time.
+func (r *LocationMongoRepository) GetLocationAsOf(id string, asOf time.Time) (*model.Location, error) {
+	objectID, err := parseID("location", id)
+	if err != nil {
+		return nil, err
+	}
+	var location model.Location
+	err = r.locationsCollection.versionAsOf(context.Background(), objectID, asOf, &location)
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// RevertLocation brings a location back to one of its versions, as long as the supplier of that version is not deleted.
+// The version is applied like an update: the stock moved by receipts and returns since is kept.
+func (r *LocationMongoRepository) RevertLocation(id string, number int, version int) error {
+	return r.unitOfWork(func(r *LocationMongoRepository) error {
+		return r.revertLocation(id, number, version)
+	})
+}
+
+func (r *LocationMongoRepository) revertLocation(id string, number int, version int) error {
+	objectID, err := parseID("location", id)
+	if err != nil {
+		return err
+	}
+	var location model.Location
+	err = r.locationsCollection.version(context.Background(), objectID, number, &location)
+	if err != nil {
+		return err
+	}
+	currentLocation, err := r.GetLocationByID(id)
+	if err != nil {
+		return err
+	}
+	location.ID = primitive.NilObjectID
+	location.Stock = currentLocation.Stock
+	return r.updateLocation(id, &location, version)
+}
+
+// BulkWrite applies a list of create, update and delete operations to locations, and reports the outcome of each.
+// With atomic, either all of them are applied or none.
+func (r *LocationMongoRepository) BulkWrite(operations []model.LocationOperation, atomic bool) ([]model.BulkResult, error) {
+	return r.locationsCollection.bulkWrite(len(operations), atomic, func(scope Scope, index int) (string, error) {
+		scoped := r.WithScope(scope)
+		operation := operations[index]
+		switch operation.Action {
+		case model.BulkActionCreate:
+			if operation.Location == nil {
+				return "", invalid("bulk operation", "location", "is required")
+			}
+			if err := scoped.CreateLocation(operation.Location); err != nil {
+				return "", err
+			}
+			return operation.Location.ID.Hex(), nil
+		case model.BulkActionUpdate:
+			if operation.Location == nil {
+				return operation.ID, invalid("bulk operation", "location", "is required")
+			}
+			return operation.ID, scoped.UpdateLocation(operation.ID, operation.Location, operation.Version)
+		case model.BulkActionDelete:
+			return operation.ID, scoped.DeleteLocation(operation.ID, operation.Version)
+		}
+		return operation.ID, errInvalidBulkAction
+	})
+}
+
+// AdjustPrices changes the price of every location of a supplier by a percentage, and returns the number
+// of locations changed. A percentage of 5 raises the prices by 5%, and one of -5 lowers them by 5%.
+func (r *LocationMongoRepository) AdjustPrices(id string, percentage float64) (int64, error) {
+	var adjusted int64
+	err := r.unitOfWork(func(r *LocationMongoRepository) error {
+		var err error
+		adjusted, err = r.adjustPrices(id, percentage)
+		return err
+	})
+	return adjusted, err
+}
+
+func (r *LocationMongoRepository) adjustPrices(id string, percentage float64) (int64, error) {
+	supplierID, err := parseID("supplier", id)
+	if err != nil {
+		return 0, err
+	}
+	if percentage <= -100 {
+		return 0, invalid("price adjustment", "percentage", "must be greater than -100")
+	}
+	if _, err := r.getSupplier(supplierID); err != nil {
+		return 0, err
+	}
+
+	result, err := r.locationsCollection.UpdateMany(context.Background(), bson.M{"supplier": supplierID}, bson.M{"$mul": bson.M{"price": 1 + percentage/100}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
 }
 
 // ListAll retrieves a list of all locations from the database.
@@ -82,10 +255,18 @@ func (r *LocationMongoRepository) ListAll() ([]model.Location, error) {
 		bson.D{{"$unwind", "$supplierInfo"}},
 		bson.D{{"$project", bson.D{
 			{"_id", 1},
+			{"tenantId", 1},
+			{"deletedAt", 1},
+			{"deletedBy", 1},
+			{"version", 1},
 			{"name", 1},
 			{"price", 1},
 			{"supplier", 1},
 			{"supplierName", "$supplierInfo.name"},
+			{"trackStock", 1},
+			{"stock", 1},
+			{"reorderPoint", 1},
+			{"reorderQuantity", 1},
 		}}},
 	}
 
@@ -106,7 +287,7 @@ func (r *LocationMongoRepository) ListAll() ([]model.Location, error) {
 func (r *LocationMongoRepository) ListBySupplier(id string) ([]model.Location, error) {
 	var locations []model.Location
 
-	supplierID, err := primitive.ObjectIDFromHex(id)
+	supplierID, err := parseID("supplier", id)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +304,161 @@ func (r *LocationMongoRepository) ListBySupplier(id string) ([]model.Location, e
 	return locations, nil
 }
 
-func (r *LocationMongoRepository) supplierExists(supplierID primitive.ObjectID) (error) {
-	count, err := r.suppliersCollection.CountDocuments(context.Background(), bson.M{"_id": supplierID})
+// ListBelowReorderPoint retrieves the tracked locations whose stock fell to their reorder point, lowest stock first.
+func (r *LocationMongoRepository) ListBelowReorderPoint() ([]model.Location, error) {
+	var locations []model.Location
+
+	opts := options.Find().SetSort(bson.M{"stock": 1})
+	cursor, err := r.locationsCollection.Find(context.Background(), reorderFilter(), opts)
 	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &locations)
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// RequestPriceChange submits a new price for a location, to be reviewed by an admin.
+// A pending request for the same location is replaced.
+func (r *LocationMongoRepository) RequestPriceChange(id string, price float64, user string) (*model.PriceChange, error) {
+	var priceChange *model.PriceChange
+	err := r.unitOfWork(func(r *LocationMongoRepository) error {
+		var err error
+		priceChange, err = r.requestPriceChange(id, price, user)
 		return err
+	})
+	return priceChange, err
+}
+
+func (r *LocationMongoRepository) requestPriceChange(id string, price float64, user string) (*model.PriceChange, error) {
+	location, err := r.GetLocationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID("user", user)
+	if err != nil {
+		return nil, err
 	}
-	if count == 0 {
-		return fmt.Errorf("supplier with ID %s does not exist", supplierID.Hex())
+	if price < 0 {
+		return nil, invalid("price change", "price", "must not be negative")
+	}
+
+	_, err = r.priceChangesCollection.DeleteMany(context.Background(), bson.M{"location": location.ID, "status": model.PriceChangePending})
+	if err != nil {
+		return nil, err
+	}
+	priceChange := model.PriceChange{
+		TenantID:       location.TenantID,
+		LocationID:     location.ID,
+		SupplierID:     location.SupplierID,
+		CurrentPrice:   location.Price,
+		RequestedPrice: price,
+		Status:         model.PriceChangePending,
+		RequestedBy:    userID,
+		RequestedAt:    time.Now(),
+	}
+	result, err := r.priceChangesCollection.InsertOne(context.Background(), priceChange)
+	if err != nil {
+		return nil, err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	priceChange.ID = insertedID
+	return &priceChange, nil
+}
+
+// ListPriceChanges retrieves the price change requests with the given status, or all of them when status is empty.
+func (r *LocationMongoRepository) ListPriceChanges(status string) ([]model.PriceChange, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	var priceChanges []model.PriceChange
+	opts := options.Find().SetSort(bson.M{"requestedAt": 1})
+	cursor, err := r.priceChangesCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &priceChanges)
+	if err != nil {
+		return nil, err
+	}
+	return priceChanges, nil
+}
+
+// ReviewPriceChange approves or rejects a pending price change. Approved prices are applied to the location.
+func (r *LocationMongoRepository) ReviewPriceChange(id string, approved bool, user string) error {
+	return r.unitOfWork(func(r *LocationMongoRepository) error {
+		return r.reviewPriceChange(id, approved, user)
+	})
+}
+
+func (r *LocationMongoRepository) reviewPriceChange(id string, approved bool, user string) error {
+	objectID, err := parseID("priceChange", id)
+	if err != nil {
+		return err
+	}
+	userID, err := parseID("user", user)
+	if err != nil {
+		return err
+	}
+
+	var priceChange model.PriceChange
+	err = r.priceChangesCollection.FindOne(context.Background(), bson.M{"_id": objectID, "status": model.PriceChangePending}).Decode(&priceChange)
+	if err == mongo.ErrNoDocuments {
+		return conflict("price change %s is not pending", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	status := model.PriceChangeRejected
+	if approved {
+		status = model.PriceChangeApproved
+		_, err = r.locationsCollection.UpdateOne(context.Background(), bson.M{"_id": priceChange.LocationID}, bson.M{"$set": bson.M{"price": priceChange.RequestedPrice}})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = r.priceChangesCollection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": bson.M{
+		"status":     status,
+		"reviewedBy": userID,
+		"reviewedAt": time.Now(),
+	}})
+	return err
+}
+
+// getSupplier retrieves the supplier with the given ID, failing if it does not exist.
+// The supplier is locked for the rest of the unit of work.
+func (r *LocationMongoRepository) getSupplier(supplierID primitive.ObjectID) (*model.Supplier, error) {
+	var supplier model.Supplier
+	err := r.suppliersCollection.FindOne(context.Background(), bson.M{"_id": supplierID}).Decode(&supplier)
+	if err == mongo.ErrNoDocuments {
+		return nil, &NotFoundError{Entity: "supplier", ID: supplierID.Hex()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.suppliersCollection.lock(context.Background(), supplierID); err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// reorderFilter matches the tracked locations whose stock fell to their reorder point.
+func reorderFilter() bson.M {
+	return bson.M{
+		"trackStock":      true,
+		"reorderQuantity": bson.M{"$gt": 0},
+		"$expr":           bson.M{"$lte": bson.A{"$stock", "$reorderPoint"}},
 	}
-	return nil
 }
@@ -0,0 +1,13 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type OrganisationRepository interface {
+	CreateOrganisation(organisation *model.Organisation) error
+	GetOrganisationByID(id string) (*model.Organisation, error)
+	UpdateOrganisation(id string, updatedOrganisation *model.Organisation, version int) error
+	DeleteOrganisation(id string, version int) error
+	RestoreOrganisation(id string) error
+	ListAll() ([]model.Organisation, error)
+	WithScope(scope Scope) OrganisationRepository
+}
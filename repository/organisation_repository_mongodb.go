@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OrganisationMongoRepository is a concrete implementation of OrganisationRepository using MongoDB.
+// Organisations are the tenants themselves, so they are never restricted to a tenant.
+type OrganisationMongoRepository struct {
+	organisationsCollection *scopedCollection
+}
+
+func NewOrganisationMongoRepository(db *mongo.Database) *OrganisationMongoRepository {
+	return &OrganisationMongoRepository{
+		organisationsCollection: newScopedCollection(db.Collection("organisations")),
+	}
+}
+
+// WithScope returns a copy of the repository auditing its writes with the actor of the scope.
+// The tenant of the scope is ignored.
+func (r *OrganisationMongoRepository) WithScope(scope Scope) OrganisationRepository {
+	scope.TenantID = primitive.NilObjectID
+	scope.AllTenants = true
+	scoped := *r
+	scoped.organisationsCollection = r.organisationsCollection.withScope(scope)
+	return &scoped
+}
+
+// CreateOrganisation adds a new organisation to the database.
+func (r *OrganisationMongoRepository) CreateOrganisation(organisation *model.Organisation) error {
+	if err := validateOrganisation(organisation); err != nil {
+		return err
+	}
+	result, err := r.organisationsCollection.InsertOne(context.Background(), organisation)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	organisation.ID = insertedID
+	return nil
+}
+
+// GetOrganisationByID retrieves an organisation by ID from the database.
+func (r *OrganisationMongoRepository) GetOrganisationByID(id string) (*model.Organisation, error) {
+	objectID, err := parseID("organisation", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var organisation model.Organisation
+	err = r.organisationsCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&organisation)
+	if err != nil {
+		return nil, notFound(err, "organisation", id)
+	}
+	return &organisation, nil
+}
+
+// UpdateOrganisation updates an existing organisation in the database.
+func (r *OrganisationMongoRepository) UpdateOrganisation(id string, updatedOrganisation *model.Organisation, version int) error {
+	if err := validateOrganisation(updatedOrganisation); err != nil {
+		return err
+	}
+	objectID, err := parseID("organisation", id)
+	if err != nil {
+		return err
+	}
+
+	updatedOrganisation.ID = objectID
+	_, err = r.organisationsCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedOrganisation})
+	if err != nil {
+		return err
+	}
+	updatedOrganisation.Version = version + 1
+	return nil
+}
+
+// DeleteOrganisation removes an organisation from the database by ID.
+// An organisation which still has users or suppliers cannot be deleted.
+func (r *OrganisationMongoRepository) DeleteOrganisation(id string, version int) error {
+	objectID, err := parseID("organisation", id)
+	if err != nil {
+		return err
+	}
+
+	return r.organisationsCollection.deleteByID(context.Background(), objectID, version)
+}
+
+// RestoreOrganisation brings back a deleted organisation.
+func (r *OrganisationMongoRepository) RestoreOrganisation(id string) error {
+	return r.organisationsCollection.restoreByID(id)
+}
+
+// ListAll retrieves all organisations from the database.
+func (r *OrganisationMongoRepository) ListAll() ([]model.Organisation, error) {
+	var organisations []model.Organisation
+	cursor, err := r.organisationsCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &organisations)
+	if err != nil {
+		return nil, err
+	}
+	return organisations, nil
+}
+
+func validateOrganisation(organisation *model.Organisation) error {
+	if len(strings.TrimSpace(organisation.Name)) == 0 {
+		return invalid("organisation", "name", "is required")
+	}
+	return nil
+}
@@ -1,12 +1,25 @@
 package repository
 
-import "github.com/sandlayth/supplier-api/model"
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+)
 
 type PurchaseRepository interface {
 	CreatePurchase(purchase *model.Purchase) error
+	CreateQuotedPurchase(purchase *model.Purchase, rfqID string, unitPrice float64) error
 	GetPurchaseByID(id string) (*model.Purchase, error)
-	UpdatePurchase(id string, updatedPurchase *model.Purchase) error
-	DeletePurchase(id string) error
+	UpdatePurchase(id string, updatedPurchase *model.Purchase, version int) error
+	DeletePurchase(id string, version int) error
+	RestorePurchase(id string) error
+	ListPurchaseVersions(id string) ([]model.Version, error)
+	GetPurchaseAsOf(id string, asOf time.Time) (*model.Purchase, error)
+	RevertPurchase(id string, number int, version int) error
+	BulkWrite(operations []model.PurchaseOperation, atomic bool) ([]model.BulkResult, error)
 	ListAll() ([]model.Purchase, error)
 	ListPurchasesByUser(user string) ([]model.Purchase, error)
+	ListPurchasesBySupplier(supplier string) ([]model.Purchase, error)
+	CreateReorderDrafts(user string) ([]model.Purchase, error)
+	WithScope(scope Scope) PurchaseRepository
 }
\ No newline at end of file
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"time"
 
@@ -11,40 +10,104 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // PurchaseMongoRepository is a concrete implementation of PurchaseRepository using MongoDB.
 type PurchaseMongoRepository struct {
-	locationsCollection *mongo.Collection
-	purchasesCollection *mongo.Collection
-	suppliersCollection *mongo.Collection
-	usersCollection     *mongo.Collection
+	locationsCollection *scopedCollection
+	purchasesCollection *scopedCollection
+	suppliersCollection *scopedCollection
+	usersCollection     *scopedCollection
+	contractsCollection *scopedCollection
+	// contractPolicy tells whether purchases outside an active contract are flagged or blocked
+	contractPolicy string
 }
 
-func NewPurchaseMongoRepository(db *mongo.Database) *PurchaseMongoRepository {
+func NewPurchaseMongoRepository(db *mongo.Database, contractPolicy string) *PurchaseMongoRepository {
 	return &PurchaseMongoRepository{
-		purchasesCollection: db.Collection("purchases"),
-		locationsCollection: db.Collection("locations"),
-		suppliersCollection: db.Collection("suppliers"),
-		usersCollection:     db.Collection("users"),
+		purchasesCollection: newScopedCollection(db.Collection("purchases")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+		suppliersCollection: newScopedCollection(db.Collection("suppliers")),
+		usersCollection:     newScopedCollection(db.Collection("users")),
+		contractsCollection: newScopedCollection(db.Collection("contracts")),
+		contractPolicy:      contractPolicy,
 	}
 }
 
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *PurchaseMongoRepository) WithScope(scope Scope) PurchaseRepository {
+	scoped := *r
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.usersCollection = r.usersCollection.withScope(scope)
+	scoped.contractsCollection = r.contractsCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *PurchaseMongoRepository) unitOfWork(fn func(r *PurchaseMongoRepository) error) error {
+	return r.purchasesCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*PurchaseMongoRepository))
+	})
+}
+
 // CreatePurchase adds a new purchase to the database.
 func (r *PurchaseMongoRepository) CreatePurchase(purchase *model.Purchase) error {
+	// Quoted prices only come from awarded RFQs
+	purchase.RFQID = primitive.NilObjectID
+	purchase.QuotedPrice = 0
+	return r.createPurchase(purchase)
+}
+
+// CreateQuotedPurchase adds a new purchase priced at the unit price quoted in an RFQ.
+func (r *PurchaseMongoRepository) CreateQuotedPurchase(purchase *model.Purchase, rfqID string, unitPrice float64) error {
+	objectID, err := parseID("rfq", rfqID)
+	if err != nil {
+		return err
+	}
+	purchase.RFQID = objectID
+	purchase.QuotedPrice = unitPrice
+	return r.createPurchase(purchase)
+}
+
+// createPurchase validates and prices a purchase, then inserts it, as one unit of work, so that
+// neither its user nor its location can be deleted in between.
+func (r *PurchaseMongoRepository) createPurchase(purchase *model.Purchase) error {
+	return r.unitOfWork(func(r *PurchaseMongoRepository) error {
+		return r.insertPurchase(purchase)
+	})
+}
+
+func (r *PurchaseMongoRepository) insertPurchase(purchase *model.Purchase) error {
 	// Validate that the specified UserID corresponds to an existing user
-	if err := r.validateUser(purchase.UserID); err != nil {
+	user, err := r.getUser(purchase.UserID)
+	if err != nil {
 		return err
 	}
+	purchase.UserName = user.Email
 
-	totalPrice, err := r.calculatePrice(purchase)
+	totalPrice, err := r.calculatePrice(purchase, time.Now())
 	if err != nil {
 		return err
 	}
 	purchase.TotalPrice = totalPrice
+	if purchase.Status == "" {
+		purchase.Status = model.PurchaseStatusOrdered
+	}
+	// Deliveries and returns are only recorded through receipts and returns
+	purchase.ReceivedQuantity = 0
+	purchase.DeliveryClosed = false
+	purchase.ReturnedQuantity = 0
+	purchase.CreditedAmount = 0
+	purchase.RefreshDelivery()
 
 	// Continue with purchase creation
 	result, err := r.purchasesCollection.InsertOne(context.Background(), purchase)
+	if err != nil {
+		return err
+	}
 
 	// Update the purchase with the new ID
 	insertedID, ok := result.InsertedID.(primitive.ObjectID)
@@ -53,12 +116,12 @@ func (r *PurchaseMongoRepository) CreatePurchase(purchase *model.Purchase) error
 		return errors.New("inserted ID is not a primitive.ObjectID")
 	}
 	purchase.ID = insertedID
-	return err
+	return nil
 }
 
 // GetPurchaseByID retrieves a purchase by ID from the database.
 func (r *PurchaseMongoRepository) GetPurchaseByID(id string) (*model.Purchase, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID("purchase", id)
 	if err != nil {
 		return nil, err
 	}
@@ -66,67 +129,240 @@ func (r *PurchaseMongoRepository) GetPurchaseByID(id string) (*model.Purchase, e
 	var purchase model.Purchase
 	err = r.purchasesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&purchase)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "purchase", id)
 	}
 	return &purchase, nil
 }
 
 // UpdatePurchase updates an existing purchase in the database.
-func (r *PurchaseMongoRepository) UpdatePurchase(id string, updatedPurchase *model.Purchase) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (r *PurchaseMongoRepository) UpdatePurchase(id string, updatedPurchase *model.Purchase, version int) error {
+	return r.unitOfWork(func(r *PurchaseMongoRepository) error {
+		return r.updatePurchase(id, updatedPurchase, version)
+	})
+}
+
+func (r *PurchaseMongoRepository) updatePurchase(id string, updatedPurchase *model.Purchase, version int) error {
+	objectID, err := parseID("purchase", id)
 	if err != nil {
 		return err
 	}
-	if err := r.validateUser(updatedPurchase.UserID); err != nil {
+	user, err := r.getUser(updatedPurchase.UserID)
+	if err != nil {
+		return err
+	}
+	updatedPurchase.UserName = user.Email
+
+	// Keep the deliveries, returns and quote recorded through their own endpoints
+	currentPurchase, err := r.GetPurchaseByID(id)
+	if err != nil {
 		return err
 	}
+	updatedPurchase.ReceivedQuantity = currentPurchase.ReceivedQuantity
+	updatedPurchase.DeliveryClosed = currentPurchase.DeliveryClosed
+	updatedPurchase.ReturnedQuantity = currentPurchase.ReturnedQuantity
+	updatedPurchase.CreditedAmount = currentPurchase.CreditedAmount
+	updatedPurchase.RFQID = currentPurchase.RFQID
+	updatedPurchase.QuotedPrice = currentPurchase.QuotedPrice
+	updatedPurchase.RefreshDelivery()
 
-	totalPrice, err := r.calculatePrice(updatedPurchase)
+	totalPrice, err := r.calculatePrice(updatedPurchase, objectID.Timestamp())
 	if err != nil {
 		return err
 	}
+	// The unit price snapshot the returns are credited at is kept while the location stays the same
+	if updatedPurchase.LocationID == currentPurchase.LocationID && currentPurchase.UnitPrice != 0 {
+		updatedPurchase.UnitPrice = currentPurchase.UnitPrice
+		totalPrice = priceOf(updatedPurchase)
+	}
 	updatedPurchase.TotalPrice = totalPrice
 
-	_, err = r.purchasesCollection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedPurchase})
-	return err
+	_, err = r.purchasesCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedPurchase})
+	if err != nil {
+		return err
+	}
+	updatedPurchase.Version = version + 1
+	return nil
 }
 
 // DeletePurchase removes a purchase from the database by ID.
-func (r *PurchaseMongoRepository) DeletePurchase(id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// A purchase with receipts, returns or invoices cannot be deleted.
+func (r *PurchaseMongoRepository) DeletePurchase(id string, version int) error {
+	objectID, err := parseID("purchase", id)
+	if err != nil {
+		return err
+	}
+
+	return r.purchasesCollection.deleteByID(context.Background(), objectID, version)
+}
+
+// RestorePurchase brings back a deleted purchase.
+func (r *PurchaseMongoRepository) RestorePurchase(id string) error {
+	return r.purchasesCollection.restoreByID(id)
+}
+
+// ListPurchaseVersions retrieves the versions of a purchase, oldest first.
+func (r *PurchaseMongoRepository) ListPurchaseVersions(id string) ([]model.Version, error) {
+	objectID, err := parseID("purchase", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.purchasesCollection.versions(context.Background(), objectID, func() interface{} { return &model.Purchase{} })
+}
+
+// GetPurchaseAsOf retrieves the state a purchase was in at the given time.
+func (r *PurchaseMongoRepository) GetPurchaseAsOf(id string, asOf time.Time) (*model.Purchase, error) {
+	objectID, err := parseID("purchase", id)
+	if err != nil {
+		return nil, err
+	}
+	var purchase model.Purchase
+	err = r.purchasesCollection.versionAsOf(context.Background(), objectID, asOf, &purchase)
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// RevertPurchase brings a purchase back to one of its versions, as long as its user and location still exist.
+// The version is applied like an update: the deliveries and returns recorded since are kept, and the purchase is priced again.
+func (r *PurchaseMongoRepository) RevertPurchase(id string, number int, version int) error {
+	return r.unitOfWork(func(r *PurchaseMongoRepository) error {
+		return r.revertPurchase(id, number, version)
+	})
+}
+
+func (r *PurchaseMongoRepository) revertPurchase(id string, number int, version int) error {
+	objectID, err := parseID("purchase", id)
 	if err != nil {
 		return err
 	}
+	var purchase model.Purchase
+	err = r.purchasesCollection.version(context.Background(), objectID, number, &purchase)
+	if err != nil {
+		return err
+	}
+	purchase.ID = primitive.NilObjectID
+	return r.updatePurchase(id, &purchase, version)
+}
 
-	_, err = r.purchasesCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
-	return err
+// BulkWrite applies a list of create, update and delete operations to purchases, and reports the outcome of each.
+// With atomic, either all of them are applied or none.
+func (r *PurchaseMongoRepository) BulkWrite(operations []model.PurchaseOperation, atomic bool) ([]model.BulkResult, error) {
+	return r.purchasesCollection.bulkWrite(len(operations), atomic, func(scope Scope, index int) (string, error) {
+		scoped := r.WithScope(scope)
+		operation := operations[index]
+		switch operation.Action {
+		case model.BulkActionCreate:
+			if operation.Purchase == nil {
+				return "", invalid("bulk operation", "purchase", "is required")
+			}
+			if err := scoped.CreatePurchase(operation.Purchase); err != nil {
+				return "", err
+			}
+			return operation.Purchase.ID.Hex(), nil
+		case model.BulkActionUpdate:
+			if operation.Purchase == nil {
+				return operation.ID, invalid("bulk operation", "purchase", "is required")
+			}
+			return operation.ID, scoped.UpdatePurchase(operation.ID, operation.Purchase, operation.Version)
+		case model.BulkActionDelete:
+			return operation.ID, scoped.DeletePurchase(operation.ID, operation.Version)
+		}
+		return operation.ID, errInvalidBulkAction
+	})
 }
 
 // ListAll retrieves a list of all purchases from the database.
 func (r *PurchaseMongoRepository) ListAll() ([]model.Purchase, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return r.aggregate(purchasePipeline(bson.D{}, true))
+}
 
-	pipeline := bson.A{
+// ListPurchasesByUser retrieves a list of purchases for a specific user from the database.
+func (r *PurchaseMongoRepository) ListPurchasesByUser(user string) ([]model.Purchase, error) {
+	userID, err := parseID("user", user)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.getUser(userID); err != nil {
+		return nil, err
+	}
+	return r.aggregate(purchasePipeline(bson.D{{"user", userID}}, true))
+}
+
+// ListPurchasesBySupplier retrieves a list of the purchases placed with a specific supplier from the database.
+// The buyers are left out, since the list is shown to the supplier.
+func (r *PurchaseMongoRepository) ListPurchasesBySupplier(supplier string) ([]model.Purchase, error) {
+	supplierID, err := parseID("supplier", supplier)
+	if err != nil {
+		return nil, err
+	}
+
+	// The purchases of the locations deleted since are still the supplier's
+	cursor, err := r.locationsCollection.withDeleted().Find(context.Background(), bson.M{"supplier": supplierID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+	var locations []model.Location
+	if err := cursor.All(context.Background(), &locations); err != nil {
+		return nil, err
+	}
+	locationIDs := bson.A{}
+	for _, location := range locations {
+		locationIDs = append(locationIDs, location.ID)
+	}
+
+	return r.aggregate(purchasePipeline(bson.D{{"location", bson.D{{"$in", locationIDs}}}}, false))
+}
+
+// purchasePipeline matches purchases and joins them with their location and supplier, for their current names.
+// The buyer of the purchases is only projected withUser.
+func purchasePipeline(match bson.D, withUser bool) bson.A {
+	projection := bson.D{
+		{"_id", 1},
+		{"tenantId", 1},
+		{"deletedAt", 1},
+		{"deletedBy", 1},
+		{"version", 1},
+		{"quantity", 1},
+		{"date", 1},
+		{"expectedDate", 1},
+		{"fees", 1},
+		{"unitPrice", 1},
+		{"totalPrice", 1},
+		{"status", 1},
+		{"receivedQuantity", 1},
+		{"outstandingQuantity", 1},
+		{"deliveryStatus", 1},
+		{"deliveryClosed", 1},
+		{"returnedQuantity", 1},
+		{"creditedAmount", 1},
+		{"location", 1},
+		{"supplier", "$supplierInfo._id"},
+		{"contract", 1},
+		{"offContract", 1},
+		{"rfq", 1},
+		{"quotedPrice", 1},
+		{"locationName", "$locationInfo.name"},
+		{"supplierName", "$supplierInfo.name"},
+	}
+	if withUser {
+		projection = append(projection, bson.E{"user", 1}, bson.E{"userName", 1})
+	}
+
+	return bson.A{
+		bson.D{{"$match", match}},
 		bson.D{{"$lookup", bson.D{{"from", "locations"}, {"localField", "location"}, {"foreignField", "_id"}, {"as", "locationInfo"}}}},
 		bson.D{{"$unwind", "$locationInfo"}},
 		bson.D{{"$lookup", bson.D{{"from", "suppliers"}, {"localField", "locationInfo.supplier"}, {"foreignField", "_id"}, {"as", "supplierInfo"}}}},
 		bson.D{{"$unwind", "$supplierInfo"}},
-		bson.D{{"$lookup", bson.D{{"from", "users"}, {"localField", "user"}, {"foreignField", "_id"}, {"as", "userInfo"}}}},
-		bson.D{{"$unwind", "$userInfo"}},
-		bson.D{{"$project", bson.D{
-			{"_id", 1},
-			{"quantity", 1},
-			{"date", 1},
-			{"fees", 1},
-			{"totalPrice", 1},
-			{"user", 1},
-			{"location", 1},
-			{"locationName", "$locationInfo.name"},
-			{"supplierName", "$supplierInfo.name"},
-			{"userName", "$userInfo.email"},
-		}}},
+		bson.D{{"$project", projection}},
 	}
+}
+
+func (r *PurchaseMongoRepository) aggregate(pipeline bson.A) ([]model.Purchase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	cursor, err := r.purchasesCollection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -138,84 +374,156 @@ func (r *PurchaseMongoRepository) ListAll() ([]model.Purchase, error) {
 	if err := cursor.All(ctx, &purchases); err != nil {
 		return nil, err
 	}
-
 	return purchases, nil
 }
 
-// ListPurchasesByUser retrieves a list of purchases for a specific user from the database.
-func (r *PurchaseMongoRepository) ListPurchasesByUser(user string) ([]model.Purchase, error) {
-	userID, err := primitive.ObjectIDFromHex(user)
+// CreateReorderDrafts creates a draft purchase, attributed to the given user, for every tracked
+// location whose stock fell to its reorder point and that has neither a pending draft nor an ordered
+// purchase still to be delivered. A location whose draft cannot be created is logged and skipped.
+func (r *PurchaseMongoRepository) CreateReorderDrafts(user string) ([]model.Purchase, error) {
+	userID, err := parseID("user", user)
 	if err != nil {
 		return nil, err
 	}
-	if err := r.validateUser(userID); err != nil {
+
+	cursor, err := r.locationsCollection.Find(context.Background(), reorderFilter())
+	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(context.Background())
 
-	var purchases []model.Purchase
+	var locations []model.Location
+	if err := cursor.All(context.Background(), &locations); err != nil {
+		return nil, err
+	}
 
-	pipeline := bson.A{
-		bson.D{{"$match", bson.D{{"user", userID}}}},
-		bson.D{{"$lookup", bson.D{{"from", "locations"}, {"localField", "location"}, {"foreignField", "_id"}, {"as", "locationInfo"}}}},
-		bson.D{{"$lookup", bson.D{{"from", "suppliers"}, {"localField", "locationInfo.supplier"}, {"foreignField", "_id"}, {"as", "supplierInfo"}}}},
-		bson.D{{"$unwind", "$locationInfo"}},
-		bson.D{{"$unwind", "$supplierInfo"}},
-		bson.D{{"$project", bson.D{
-			{"_id", 1},
-			{"quantity", 1},
-			{"date", 1},
-			{"fees", 1},
-			{"totalPrice", 1},
-			{"user", 1},
-			{"location", 1},
-			{"locationName", "$locationInfo.name"},
-			{"supplierName", "$supplierInfo.name"},
-		}}},
+	drafts := []model.Purchase{}
+	for _, location := range locations {
+		// Skip the locations which already wait for a draft to be confirmed or for an order to be delivered
+		pending, err := r.purchasesCollection.CountDocuments(context.Background(), bson.M{"location": location.ID, "$or": bson.A{
+			bson.M{"status": model.PurchaseStatusDraft},
+			bson.M{"status": model.PurchaseStatusOrdered, "outstandingQuantity": bson.M{"$gt": 0}},
+		}})
+		if err != nil {
+			return drafts, err
+		}
+		if pending > 0 {
+			continue
+		}
+
+		draft := model.Purchase{
+			Quantity:   location.ReorderQuantity,
+			Date:       time.Now(),
+			Status:     model.PurchaseStatusDraft,
+			UserID:     userID,
+			LocationID: location.ID,
+		}
+		if err := r.CreatePurchase(&draft); err != nil {
+			log.Printf("Creating a draft purchase for location %s failed: %v\n", location.ID.Hex(), err)
+			continue
+		}
+
+		drafts = append(drafts, draft)
 	}
+	return drafts, nil
+}
 
-	cursor, err := r.purchasesCollection.Aggregate(context.Background(), pipeline)
+// calculatePrice calculate the price of the purchase (quantity * price * (1 - fees)) at the unit price it sets
+func (r *PurchaseMongoRepository) calculatePrice(purchase *model.Purchase, createdAt time.Time) (float64, error) {
+	// Retrieve the corresponding location to get the price
+	location, err := r.getLocationByID(purchase.LocationID)
+	if err != nil {
+		return 0.0, err
+	}
+	unitPrice := location.Price
+	purchase.SupplierID = location.SupplierID
+	purchase.LocationName = location.Name
+	purchase.TenantID = location.TenantID
 
+	var supplier model.Supplier
+	err = r.suppliersCollection.FindOne(context.Background(), bson.M{"_id": location.SupplierID}).Decode(&supplier)
 	if err != nil {
-		return nil, err
+		return 0.0, notFound(err, "supplier", location.SupplierID.Hex())
+	}
+	purchase.SupplierName = supplier.Name
+	// Suspended and blocked suppliers cannot be ordered from
+	if !supplier.CanBeOrderedFrom() {
+		return 0.0, conflict("supplier %s is %s", supplier.Name, supplier.Status)
 	}
-	defer cursor.Close(context.Background())
 
-	err = cursor.All(context.Background(), &purchases)
+	// The price locked by a contract wins over the location price. The contract is the one in force when the
+	// purchase was created, rather than at its date, which the client chooses
+	contract, err := r.getActiveContract(location.SupplierID, createdAt)
 	if err != nil {
-		return nil, err
+		return 0.0, err
 	}
-	return purchases, nil
+	purchase.ContractID = primitive.NilObjectID
+	purchase.OffContract = contract == nil || purchase.Quantity < contract.MinimumOrderQuantity
+	if contract != nil {
+		purchase.ContractID = contract.ID
+		if contractPrice, ok := contract.PriceFor(location.ID); ok {
+			unitPrice = contractPrice
+		}
+	}
+	// An awarded quote is an agreement of its own, whose price wins over the contract one
+	if !purchase.RFQID.IsZero() {
+		unitPrice = purchase.QuotedPrice
+		purchase.OffContract = false
+	}
+	// Purchases outside of any agreement are flagged, or refused when the contract policy blocks them
+	if purchase.OffContract && r.contractPolicy == model.ContractPolicyBlock {
+		return 0.0, conflict("purchase is outside of an active contract with supplier %s", location.SupplierID.Hex())
+	}
+
+	purchase.UnitPrice = unitPrice
+	return priceOf(purchase), nil
 }
 
-// calculatePrice calculate the price of the purchase (quantity * price * (1 - fees))
-func (r *PurchaseMongoRepository) calculatePrice(purchase *model.Purchase) (float64, error) {
-	// Retrieve the corresponding location to get the price
-	location, err := r.getLocationByID(purchase.LocationID)
+// priceOf returns the price of a purchase at its unit price snapshot.
+func priceOf(purchase *model.Purchase) float64 {
+	return float64(purchase.Quantity) * purchase.UnitPrice * (1 - purchase.Fees)
+}
+
+// getActiveContract retrieves the contract of the supplier in force at the given date, if any.
+func (r *PurchaseMongoRepository) getActiveContract(supplierID primitive.ObjectID, date time.Time) (*model.Contract, error) {
+	var contract model.Contract
+	filter := bson.M{"supplier": supplierID, "startDate": bson.M{"$lte": date}, "endDate": bson.M{"$gte": date}}
+	err := r.contractsCollection.FindOne(context.Background(), filter).Decode(&contract)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
-	price := float64(purchase.Quantity) * location.Price * (1 - purchase.Fees)
-	return price, nil
+	return &contract, nil
 }
 
-// validateUser checks if a user with the given ID exists.
-func (r *PurchaseMongoRepository) validateUser(userID primitive.ObjectID) error {
-	// Check if the specified UserID exists in the User collection
-	userCount, err := r.usersCollection.CountDocuments(context.Background(), bson.M{"_id": userID})
+// getUser retrieves the user with the given ID, failing if it does not exist.
+// The user is locked for the rest of the unit of work.
+func (r *PurchaseMongoRepository) getUser(userID primitive.ObjectID) (*model.User, error) {
+	var user model.User
+	err := r.usersCollection.FindOne(context.Background(), bson.M{"_id": userID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, &NotFoundError{Entity: "user", ID: userID.Hex()}
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if userCount == 0 {
-		return fmt.Errorf("user with ID %s does not exist", userID.Hex())
+	if err := r.usersCollection.lock(context.Background(), userID); err != nil {
+		return nil, err
 	}
-	return nil
+	return &user, nil
 }
 
 // getLocationByID retrieves a location by ID from the database.
+// The location is locked for the rest of the unit of work.
 func (r *PurchaseMongoRepository) getLocationByID(locationID primitive.ObjectID) (*model.Location, error) {
 	var location model.Location
 	err := r.locationsCollection.FindOne(context.Background(), bson.M{"_id": locationID}).Decode(&location)
 	if err != nil {
+		return nil, notFound(err, "location", locationID.Hex())
+	}
+	if err := r.locationsCollection.lock(context.Background(), locationID); err != nil {
 		return nil, err
 	}
 	return &location, nil
@@ -0,0 +1,9 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type ReceiptRepository interface {
+	CreateReceipt(purchaseID string, receipt *model.Receipt) error
+	ListByPurchase(purchaseID string) ([]model.Receipt, error)
+	WithScope(scope Scope) ReceiptRepository
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReceiptMongoRepository is a concrete implementation of ReceiptRepository using MongoDB.
+type ReceiptMongoRepository struct {
+	receiptsCollection  *scopedCollection
+	purchasesCollection *scopedCollection
+	locationsCollection *scopedCollection
+	usersCollection     *scopedCollection
+}
+
+func NewReceiptMongoRepository(db *mongo.Database) *ReceiptMongoRepository {
+	return &ReceiptMongoRepository{
+		receiptsCollection:  newScopedCollection(db.Collection("receipts")),
+		purchasesCollection: newScopedCollection(db.Collection("purchases")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+		usersCollection:     newScopedCollection(db.Collection("users")),
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *ReceiptMongoRepository) WithScope(scope Scope) ReceiptRepository {
+	scoped := *r
+	scoped.receiptsCollection = r.receiptsCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.usersCollection = r.usersCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *ReceiptMongoRepository) unitOfWork(fn func(r *ReceiptMongoRepository) error) error {
+	return r.receiptsCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*ReceiptMongoRepository))
+	})
+}
+
+// CreateReceipt records goods received for a purchase, updates its delivery status and,
+// when the location tracks stock, adds the received quantity to it.
+func (r *ReceiptMongoRepository) CreateReceipt(purchaseID string, receipt *model.Receipt) error {
+	return r.unitOfWork(func(r *ReceiptMongoRepository) error {
+		return r.createReceipt(purchaseID, receipt)
+	})
+}
+
+func (r *ReceiptMongoRepository) createReceipt(purchaseID string, receipt *model.Receipt) error {
+	objectID, err := parseID("purchase", purchaseID)
+	if err != nil {
+		return err
+	}
+	if receipt.Quantity < 0 || (receipt.Quantity == 0 && !receipt.Final) {
+		return invalid("receipt", "quantity", "must be positive")
+	}
+
+	var receiver model.User
+	err = r.usersCollection.FindOne(context.Background(), bson.M{"_id": receipt.ReceiverID}).Decode(&receiver)
+	if err != nil {
+		return notFound(err, "user", receipt.ReceiverID.Hex())
+	}
+	if err := r.usersCollection.lock(context.Background(), receiver.ID); err != nil {
+		return err
+	}
+
+	// The quantity is added by the update itself, which only matches an open delivery, so that
+	// concurrent receipts neither lose an increment nor land on a delivery closed in between
+	result, err := r.purchasesCollection.UpdateOne(context.Background(), bson.M{
+		"_id":            objectID,
+		"status":         bson.M{"$ne": model.PurchaseStatusDraft},
+		"deliveryClosed": bson.M{"$ne": true},
+	}, bson.M{
+		"$inc": bson.M{"receivedQuantity": receipt.Quantity},
+		"$set": bson.M{"deliveryClosed": receipt.Final},
+	})
+	if err != nil {
+		return err
+	}
+	var purchase model.Purchase
+	err = r.purchasesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&purchase)
+	if err != nil {
+		return notFound(err, "purchase", purchaseID)
+	}
+	if result.MatchedCount == 0 {
+		if purchase.Status == model.PurchaseStatusDraft {
+			return conflict("purchase %s is still a draft", purchaseID)
+		}
+		return conflict("delivery of purchase %s is already closed", purchaseID)
+	}
+	purchase.RefreshDelivery()
+
+	receipt.PurchaseID = objectID
+	receipt.TenantID = purchase.TenantID
+	receipt.ReceiverName = receiver.Email
+	receipt.OverDelivery = purchase.DeliveryStatus == model.DeliveryStatusOver
+	receipt.UnderDelivery = purchase.DeliveryStatus == model.DeliveryStatusUnder
+	if receipt.Date.IsZero() {
+		receipt.Date = time.Now()
+	}
+
+	inserted, err := r.receiptsCollection.InsertOne(context.Background(), receipt)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := inserted.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	receipt.ID = insertedID
+
+	// The delivery status derived from a quantity a concurrent receipt changed since is left to that receipt
+	_, err = r.purchasesCollection.UpdateOne(context.Background(), bson.M{
+		"_id":              objectID,
+		"receivedQuantity": purchase.ReceivedQuantity,
+		"deliveryClosed":   purchase.DeliveryClosed,
+	}, bson.M{"$set": bson.M{
+		"outstandingQuantity": purchase.OutstandingQuantity,
+		"deliveryStatus":      purchase.DeliveryStatus,
+	}})
+
+	if err != nil {
+		return err
+	}
+
+	// Only the locations tracking their stock are affected
+	_, err = r.locationsCollection.UpdateOne(context.Background(), bson.M{"_id": purchase.LocationID, "trackStock": true}, bson.M{"$inc": bson.M{"stock": receipt.Quantity}})
+	return err
+}
+
+// ListByPurchase retrieves the receipts of a purchase, oldest first.
+func (r *ReceiptMongoRepository) ListByPurchase(purchaseID string) ([]model.Receipt, error) {
+	objectID, err := parseID("purchase", purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipts []model.Receipt
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := r.receiptsCollection.Find(context.Background(), bson.M{"purchase": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &receipts)
+	if err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
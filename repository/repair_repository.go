@@ -0,0 +1,5 @@
+package repository
+
+type RepairRepository interface {
+	RepairNames() (int64, error)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RepairMongoRepository is a concrete implementation of RepairRepository using MongoDB.
+// It works across every organisation, on the deleted documents as well.
+type RepairMongoRepository struct {
+	db *mongo.Database
+}
+
+func NewRepairMongoRepository(db *mongo.Database) *RepairMongoRepository {
+	return &RepairMongoRepository{db: db}
+}
+
+// RepairNames rescans every document whose names are copied into others and fixes the copies
+// which are out of date, and returns how many documents were fixed.
+func (r *RepairMongoRepository) RepairNames() (int64, error) {
+	var collections []string
+	for collection := range denormalisations {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	var repaired int64
+	for _, collection := range collections {
+		fixed, err := newScopedCollection(r.db.Collection(collection)).repairNames(context.Background())
+		repaired += fixed
+		if err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
@@ -0,0 +1,7 @@
+package repository
+
+import "time"
+
+type RetentionRepository interface {
+	PurgeDeleted(retention time.Duration) (int64, error)
+}
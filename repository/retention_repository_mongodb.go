@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// softDeletedCollections are the collections whose documents are soft deleted.
+var softDeletedCollections = []string{
+	"organisations",
+	"users",
+	"suppliers",
+	"locations",
+	"priceChanges",
+	"contracts",
+	"purchases",
+	"receipts",
+	"returns",
+	"invoices",
+	"rfqs",
+}
+
+// RetentionMongoRepository is a concrete implementation of RetentionRepository using MongoDB.
+type RetentionMongoRepository struct {
+	collections []*scopedCollection
+}
+
+func NewRetentionMongoRepository(db *mongo.Database) *RetentionMongoRepository {
+	repository := &RetentionMongoRepository{}
+	for _, name := range softDeletedCollections {
+		repository.collections = append(repository.collections, newScopedCollection(db.Collection(name)))
+	}
+	return repository
+}
+
+// PurgeDeleted permanently removes the documents deleted for longer than the retention period,
+// and returns how many were removed.
+func (r *RetentionMongoRepository) PurgeDeleted(retention time.Duration) (int64, error) {
+	deletedBefore := time.Now().Add(-retention)
+	var purged int64
+	for _, collection := range r.collections {
+		result, err := collection.Purge(context.Background(), deletedBefore)
+		if err != nil {
+			return purged, err
+		}
+		purged += result.DeletedCount
+	}
+	return purged, nil
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type ReturnRepository interface {
+	CreateReturn(purchaseID string, purchaseReturn *model.PurchaseReturn) error
+	ListByPurchase(purchaseID string) ([]model.PurchaseReturn, error)
+	WithScope(scope Scope) ReturnRepository
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReturnMongoRepository is a concrete implementation of ReturnRepository using MongoDB.
+type ReturnMongoRepository struct {
+	returnsCollection   *scopedCollection
+	purchasesCollection *scopedCollection
+	locationsCollection *scopedCollection
+	usersCollection     *scopedCollection
+}
+
+func NewReturnMongoRepository(db *mongo.Database) *ReturnMongoRepository {
+	return &ReturnMongoRepository{
+		returnsCollection:   newScopedCollection(db.Collection("returns")),
+		purchasesCollection: newScopedCollection(db.Collection("purchases")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+		usersCollection:     newScopedCollection(db.Collection("users")),
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *ReturnMongoRepository) WithScope(scope Scope) ReturnRepository {
+	scoped := *r
+	scoped.returnsCollection = r.returnsCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.usersCollection = r.usersCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *ReturnMongoRepository) unitOfWork(fn func(r *ReturnMongoRepository) error) error {
+	return r.returnsCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*ReturnMongoRepository))
+	})
+}
+
+// CreateReturn records goods returned from a purchase. The credit is derived from the unit price
+// snapshot of the purchase and, when the location tracks stock, the returned quantity is removed from it.
+func (r *ReturnMongoRepository) CreateReturn(purchaseID string, purchaseReturn *model.PurchaseReturn) error {
+	return r.unitOfWork(func(r *ReturnMongoRepository) error {
+		return r.createReturn(purchaseID, purchaseReturn)
+	})
+}
+
+func (r *ReturnMongoRepository) createReturn(purchaseID string, purchaseReturn *model.PurchaseReturn) error {
+	objectID, err := parseID("purchase", purchaseID)
+	if err != nil {
+		return err
+	}
+	if purchaseReturn.Quantity <= 0 {
+		return invalid("return", "quantity", "must be positive")
+	}
+	if len(strings.TrimSpace(purchaseReturn.Reason)) == 0 {
+		return invalid("return", "reason", "is required")
+	}
+
+	var purchase model.Purchase
+	err = r.purchasesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&purchase)
+	if err != nil {
+		return notFound(err, "purchase", purchaseID)
+	}
+	var user model.User
+	err = r.usersCollection.FindOne(context.Background(), bson.M{"_id": purchaseReturn.UserID}).Decode(&user)
+	if err != nil {
+		return notFound(err, "user", purchaseReturn.UserID.Hex())
+	}
+	if err := r.usersCollection.lock(context.Background(), user.ID); err != nil {
+		return err
+	}
+
+	purchaseReturn.PurchaseID = objectID
+	purchaseReturn.TenantID = purchase.TenantID
+	purchaseReturn.UserName = user.Email
+	purchaseReturn.CreditAmount = float64(purchaseReturn.Quantity) * purchase.UnitCredit()
+	if purchaseReturn.Date.IsZero() {
+		purchaseReturn.Date = time.Now()
+	}
+
+	// The update only matches while enough received units are left to return, so that
+	// concurrent returns cannot return more than was received between them
+	result, err := r.purchasesCollection.UpdateOne(context.Background(), bson.M{
+		"_id": objectID,
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$returnedQuantity", 0}}, purchaseReturn.Quantity}},
+			bson.M{"$ifNull": bson.A{"$receivedQuantity", 0}},
+		}},
+	}, bson.M{"$inc": bson.M{
+		"returnedQuantity": purchaseReturn.Quantity,
+		"creditedAmount":   purchaseReturn.CreditAmount,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		err = r.purchasesCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&purchase)
+		if err != nil {
+			return notFound(err, "purchase", purchaseID)
+		}
+		returnable := purchase.ReceivedQuantity - purchase.ReturnedQuantity
+		return invalid("return", "quantity", fmt.Sprintf("exceeds the %d received units of purchase %s which can be returned", returnable, purchaseID))
+	}
+
+	inserted, err := r.returnsCollection.InsertOne(context.Background(), purchaseReturn)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := inserted.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	purchaseReturn.ID = insertedID
+
+	// Only the locations tracking their stock are affected
+	_, err = r.locationsCollection.UpdateOne(context.Background(), bson.M{"_id": purchase.LocationID, "trackStock": true}, bson.M{"$inc": bson.M{"stock": -purchaseReturn.Quantity}})
+	return err
+}
+
+// ListByPurchase retrieves the returns of a purchase, oldest first.
+func (r *ReturnMongoRepository) ListByPurchase(purchaseID string) ([]model.PurchaseReturn, error) {
+	objectID, err := parseID("purchase", purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var returns []model.PurchaseReturn
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := r.returnsCollection.Find(context.Background(), bson.M{"purchase": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &returns)
+	if err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "github.com/sandlayth/supplier-api/model"
+
+type RFQRepository interface {
+	CreateRFQ(rfq *model.RFQ) error
+	GetRFQByID(id string) (*model.RFQ, error)
+	ListAll() ([]model.RFQ, error)
+	SubmitQuote(id string, quote *model.Quote) error
+	SubmitQuoteByToken(token string, quote *model.Quote) error
+	CompareQuotes(id string) ([]model.QuoteComparison, error)
+	AwardQuote(id string, quoteID string, user string) (*model.RFQ, error)
+	WithScope(scope Scope) RFQRepository
+}
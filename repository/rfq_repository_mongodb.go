@@ -0,0 +1,377 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"strings"
+	"time"
+
+	"github.com/sandlayth/supplier-api/helper"
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RFQMongoRepository is a concrete implementation of RFQRepository using MongoDB.
+// Quotes are embedded in their RFQ; awarding a quote creates its purchases through the purchase repository.
+type RFQMongoRepository struct {
+	rfqsCollection      *scopedCollection
+	suppliersCollection *scopedCollection
+	locationsCollection *scopedCollection
+	purchases           PurchaseRepository
+}
+
+func NewRFQMongoRepository(db *mongo.Database, purchases PurchaseRepository) *RFQMongoRepository {
+	return &RFQMongoRepository{
+		rfqsCollection:      newScopedCollection(db.Collection("rfqs")),
+		suppliersCollection: newScopedCollection(db.Collection("suppliers")),
+		locationsCollection: newScopedCollection(db.Collection("locations")),
+		purchases:           purchases,
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *RFQMongoRepository) WithScope(scope Scope) RFQRepository {
+	scoped := *r
+	scoped.rfqsCollection = r.rfqsCollection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.purchases = r.purchases.WithScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *RFQMongoRepository) unitOfWork(fn func(r *RFQMongoRepository) error) error {
+	return r.rfqsCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*RFQMongoRepository))
+	})
+}
+
+// CreateRFQ adds a new RFQ to the database, with a quote token for every invited supplier.
+func (r *RFQMongoRepository) CreateRFQ(rfq *model.RFQ) error {
+	return r.unitOfWork(func(r *RFQMongoRepository) error {
+		return r.createRFQ(rfq)
+	})
+}
+
+func (r *RFQMongoRepository) createRFQ(rfq *model.RFQ) error {
+	if len(strings.TrimSpace(rfq.Title)) == 0 {
+		return invalid("rfq", "title", "is required")
+	}
+	if len(rfq.Items) == 0 {
+		return invalid("rfq", "items", "needs at least one item")
+	}
+	for i, item := range rfq.Items {
+		if item.Quantity <= 0 {
+			return invalid("rfq", fmt.Sprintf("items[%d].quantity", i), "must be positive")
+		}
+	}
+	if len(rfq.Invitations) == 0 {
+		return invalid("rfq", "invitations", "needs at least one invited supplier")
+	}
+	if !rfq.Deadline.After(time.Now()) {
+		return invalid("rfq", "deadline", "must be in the future")
+	}
+
+	for i := range rfq.Invitations {
+		count, err := r.suppliersCollection.CountDocuments(context.Background(), bson.M{"_id": rfq.Invitations[i].SupplierID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return &NotFoundError{Entity: "supplier", ID: rfq.Invitations[i].SupplierID.Hex()}
+		}
+		if err := r.suppliersCollection.lock(context.Background(), rfq.Invitations[i].SupplierID); err != nil {
+			return err
+		}
+		token, err := helper.GenerateRandomToken()
+		if err != nil {
+			return err
+		}
+		rfq.Invitations[i].Token = token
+	}
+	rfq.Status = model.RFQStatusOpen
+	rfq.Quotes = []model.Quote{}
+	rfq.AwardedQuoteID = primitive.NilObjectID
+	rfq.PurchaseIDs = []primitive.ObjectID{}
+
+	result, err := r.rfqsCollection.InsertOne(context.Background(), rfq)
+	if err != nil {
+		return err
+	}
+	insertedID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("inserted ID is not a primitive.ObjectID")
+	}
+	rfq.ID = insertedID
+	return nil
+}
+
+// GetRFQByID retrieves an RFQ by ID from the database.
+func (r *RFQMongoRepository) GetRFQByID(id string) (*model.RFQ, error) {
+	objectID, err := parseID("rfq", id)
+	if err != nil {
+		return nil, err
+	}
+	rfq, err := r.findOne(bson.M{"_id": objectID})
+	if err != nil {
+		return nil, notFound(err, "rfq", id)
+	}
+	return rfq, nil
+}
+
+// ListAll retrieves a list of all RFQs from the database, closest deadline first.
+func (r *RFQMongoRepository) ListAll() ([]model.RFQ, error) {
+	var rfqs []model.RFQ
+	cursor, err := r.rfqsCollection.Find(context.Background(), bson.M{}, options.Find().SetSort(bson.M{"deadline": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &rfqs)
+	if err != nil {
+		return nil, err
+	}
+	return rfqs, nil
+}
+
+// SubmitQuote records the quote of an invited supplier, replacing its previous one.
+func (r *RFQMongoRepository) SubmitQuote(id string, quote *model.Quote) error {
+	return r.unitOfWork(func(r *RFQMongoRepository) error {
+		return r.submitQuote(id, quote)
+	})
+}
+
+func (r *RFQMongoRepository) submitQuote(id string, quote *model.Quote) error {
+	rfq, err := r.GetRFQByID(id)
+	if err != nil {
+		return err
+	}
+	invited := false
+	for _, invitation := range rfq.Invitations {
+		invited = invited || invitation.SupplierID == quote.SupplierID
+	}
+	if !invited {
+		return &ForbiddenError{Message: fmt.Sprintf("supplier %s was not invited to this RFQ", quote.SupplierID.Hex())}
+	}
+	return r.saveQuote(rfq, quote)
+}
+
+// SubmitQuoteByToken records the quote of the supplier the token was issued to.
+func (r *RFQMongoRepository) SubmitQuoteByToken(token string, quote *model.Quote) error {
+	return r.unitOfWork(func(r *RFQMongoRepository) error {
+		return r.submitQuoteByToken(token, quote)
+	})
+}
+
+func (r *RFQMongoRepository) submitQuoteByToken(token string, quote *model.Quote) error {
+	if token == "" {
+		return &NotFoundError{Entity: "rfq", Message: "no RFQ invitation was issued with this token"}
+	}
+	rfq, err := r.findOne(bson.M{"invitations.token": token})
+	if err == mongo.ErrNoDocuments {
+		return &NotFoundError{Entity: "rfq", Message: "no RFQ invitation was issued with this token"}
+	}
+	if err != nil {
+		return err
+	}
+	for _, invitation := range rfq.Invitations {
+		if invitation.Token == token {
+			quote.SupplierID = invitation.SupplierID
+		}
+	}
+	return r.saveQuote(rfq, quote)
+}
+
+// CompareQuotes puts the quotes of an RFQ side by side, complete quotes first and cheapest first.
+func (r *RFQMongoRepository) CompareQuotes(id string) ([]model.QuoteComparison, error) {
+	rfq, err := r.GetRFQByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := make([]model.QuoteComparison, 0, len(rfq.Quotes))
+	for _, quote := range rfq.Quotes {
+		var supplier model.Supplier
+		err := r.suppliersCollection.FindOne(context.Background(), bson.M{"_id": quote.SupplierID}).Decode(&supplier)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		comparison := model.QuoteComparison{
+			QuoteID:      quote.ID,
+			SupplierID:   quote.SupplierID,
+			SupplierName: supplier.Name,
+			ItemPrices:   make([]*float64, len(rfq.Items)),
+			Total:        quote.Total,
+			LeadTimeDays: quote.LeadTimeDays,
+		}
+		for _, line := range quote.Lines {
+			unitPrice := line.UnitPrice
+			comparison.ItemPrices[line.Item] = &unitPrice
+		}
+		comparison.Complete = quoteIsComplete(rfq, &quote)
+		comparisons = append(comparisons, comparison)
+	}
+
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		if comparisons[i].Complete != comparisons[j].Complete {
+			return comparisons[i].Complete
+		}
+		return comparisons[i].Total < comparisons[j].Total
+	})
+	return comparisons, nil
+}
+
+// AwardQuote awards an RFQ to one of its complete quotes and converts the quote into purchases,
+// one per item, placed by the given user at the quoted prices.
+func (r *RFQMongoRepository) AwardQuote(id string, quoteID string, user string) (*model.RFQ, error) {
+	var awarded *model.RFQ
+	err := r.unitOfWork(func(r *RFQMongoRepository) error {
+		var err error
+		awarded, err = r.awardQuote(id, quoteID, user)
+		return err
+	})
+	return awarded, err
+}
+
+func (r *RFQMongoRepository) awardQuote(id string, quoteID string, user string) (*model.RFQ, error) {
+	rfq, err := r.GetRFQByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rfq.Status != model.RFQStatusOpen {
+		return nil, conflict("RFQ %s was already awarded", id)
+	}
+	userID, err := parseID("user", user)
+	if err != nil {
+		return nil, err
+	}
+
+	var quote *model.Quote
+	for i := range rfq.Quotes {
+		if rfq.Quotes[i].ID.Hex() == quoteID {
+			quote = &rfq.Quotes[i]
+		}
+	}
+	if quote == nil {
+		return nil, &NotFoundError{Entity: "quote", Message: fmt.Sprintf("quote %s does not belong to RFQ %s", quoteID, id)}
+	}
+	if !quoteIsComplete(rfq, quote) {
+		return nil, conflict("only a quote covering every item can be awarded")
+	}
+
+	// Claim the RFQ before creating any purchase, so that concurrent or retried awards
+	// do not each create purchases, whether the unit of work runs in a transaction or not
+	claimed, err := r.rfqsCollection.UpdateOne(context.Background(), bson.M{"_id": rfq.ID, "status": model.RFQStatusOpen}, bson.M{"$set": bson.M{
+		"status":       model.RFQStatusAwarded,
+		"awardedQuote": quote.ID,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if claimed.MatchedCount == 0 {
+		return nil, conflict("RFQ %s was already awarded", id)
+	}
+
+	purchaseIDs := []primitive.ObjectID{}
+	for _, line := range quote.Lines {
+		purchase := model.Purchase{
+			Quantity:     rfq.Items[line.Item].Quantity,
+			Date:         time.Now(),
+			ExpectedDate: time.Now().AddDate(0, 0, quote.LeadTimeDays),
+			UserID:       userID,
+			LocationID:   line.LocationID,
+		}
+		if err := r.purchases.CreateQuotedPurchase(&purchase, id, line.UnitPrice); err != nil {
+			r.releaseAward(rfq.ID, quote.ID)
+			return nil, err
+		}
+		purchaseIDs = append(purchaseIDs, purchase.ID)
+	}
+
+	_, err = r.rfqsCollection.UpdateOne(context.Background(), bson.M{"_id": rfq.ID}, bson.M{"$set": bson.M{"purchases": purchaseIDs}})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetRFQByID(id)
+}
+
+// releaseAward reopens an RFQ claimed by an award whose purchases could not be created.
+// In a transaction the claim is rolled back anyway.
+func (r *RFQMongoRepository) releaseAward(rfqID primitive.ObjectID, quoteID primitive.ObjectID) {
+	_, err := r.rfqsCollection.UpdateOne(context.Background(), bson.M{"_id": rfqID, "awardedQuote": quoteID}, bson.M{
+		"$set":   bson.M{"status": model.RFQStatusOpen},
+		"$unset": bson.M{"awardedQuote": ""},
+	})
+	if err != nil {
+		log.Printf("Reopening RFQ %s failed: %v\n", rfqID.Hex(), err)
+	}
+}
+
+// saveQuote validates a quote against its RFQ and stores it in place of the previous quote of the supplier.
+func (r *RFQMongoRepository) saveQuote(rfq *model.RFQ, quote *model.Quote) error {
+	if rfq.Status != model.RFQStatusOpen {
+		return conflict("RFQ %s is not open anymore", rfq.ID.Hex())
+	}
+	if time.Now().After(rfq.Deadline) {
+		return conflict("the deadline of RFQ %s has passed", rfq.ID.Hex())
+	}
+	if len(quote.Lines) == 0 {
+		return invalid("quote", "lines", "needs at least one line")
+	}
+	if quote.LeadTimeDays < 0 {
+		return invalid("quote", "leadTimeDays", "must not be negative")
+	}
+
+	quoted := map[int]bool{}
+	quote.Total = 0
+	for i, line := range quote.Lines {
+		field := fmt.Sprintf("lines[%d]", i)
+		if line.Item < 0 || line.Item >= len(rfq.Items) || quoted[line.Item] {
+			return invalid("quote", field+".item", "is not an item of the RFQ, or is quoted twice")
+		}
+		if line.UnitPrice < 0 {
+			return invalid("quote", field+".unitPrice", "must not be negative")
+		}
+		count, err := r.locationsCollection.CountDocuments(context.Background(), bson.M{"_id": line.LocationID, "supplier": quote.SupplierID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return invalid("quote", field+".location", "is not a location of the supplier")
+		}
+		quoted[line.Item] = true
+		quote.Total += line.UnitPrice * float64(rfq.Items[line.Item].Quantity)
+	}
+	quote.ID = primitive.NewObjectID()
+	quote.SubmittedAt = time.Now()
+
+	_, err := r.rfqsCollection.UpdateOne(context.Background(), bson.M{"_id": rfq.ID}, bson.M{"$pull": bson.M{"quotes": bson.M{"supplier": quote.SupplierID}}})
+	if err != nil {
+		return err
+	}
+	_, err = r.rfqsCollection.UpdateOne(context.Background(), bson.M{"_id": rfq.ID}, bson.M{"$push": bson.M{"quotes": quote}})
+	return err
+}
+
+func (r *RFQMongoRepository) findOne(filter bson.M) (*model.RFQ, error) {
+	var rfq model.RFQ
+	err := r.rfqsCollection.FindOne(context.Background(), filter).Decode(&rfq)
+	if err != nil {
+		return nil, err
+	}
+	return &rfq, nil
+}
+
+// quoteIsComplete reports whether the quote prices every item of the RFQ.
+func quoteIsComplete(rfq *model.RFQ, quote *model.Quote) bool {
+	return len(quote.Lines) == len(rfq.Items)
+}
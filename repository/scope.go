@@ -0,0 +1,496 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// tenantField is the field holding the organisation a document belongs to.
+const tenantField = "tenantId"
+
+// versionField is the number of writes made to a document, which conditional writes expect.
+const versionField = "version"
+
+// ErrVersionMismatch is returned by a conditional write when the document is no longer at the expected version.
+var ErrVersionMismatch = errors.New("the record was changed since it was read")
+
+// deletedAtField and deletedByField mark a soft deleted document, and who deleted it.
+const (
+	deletedAtField = "deletedAt"
+	deletedByField = "deletedBy"
+)
+
+// Scope restricts what a repository can see and change.
+// A scope bound to a tenant only reaches the documents of that organisation, and documents
+// created through it are assigned to the organisation. The zero tenant stands for the data
+// created before organisations existed. AllTenants lifts the restriction, for super-admins
+// and background jobs. Soft deleted documents are out of reach unless IncludeDeleted is set.
+// The actor, request ID and IP are recorded in the audit log for every write made through the scope.
+// A scope bound to a transaction makes every operation made through it part of the transaction.
+type Scope struct {
+	TenantID       primitive.ObjectID
+	AllTenants     bool
+	IncludeDeleted bool
+	ActorID        primitive.ObjectID
+	RequestID      string
+	IP             string
+	transaction    mongo.SessionContext
+}
+
+// AllTenantsScope is the scope of the repositories returned by the constructors.
+var AllTenantsScope = Scope{AllTenants: true}
+
+// scopedCollection wraps a collection so that every query is restricted to the scope.
+// It exposes the subset of the *mongo.Collection API used by the repositories. Deletes are soft:
+// they only mark the documents, which are hidden from then on and can be restored until purged.
+// Every write increments the version of the documents it changes.
+type scopedCollection struct {
+	collection      *mongo.Collection
+	scope           Scope
+	expectedVersion *int
+}
+
+func newScopedCollection(collection *mongo.Collection) *scopedCollection {
+	return &scopedCollection{collection: collection, scope: AllTenantsScope}
+}
+
+// bind returns the context of the transaction the scope is bound to, if any, in place of ctx.
+func (c *scopedCollection) bind(ctx context.Context) context.Context {
+	if c.scope.transaction != nil {
+		return c.scope.transaction
+	}
+	return ctx
+}
+
+// withScope returns the same collection restricted to another scope.
+func (c *scopedCollection) withScope(scope Scope) *scopedCollection {
+	return &scopedCollection{collection: c.collection, scope: scope}
+}
+
+// ifVersion returns the same collection, whose writes fail with ErrVersionMismatch unless
+// the document is still at the given version. The documents never written since versions
+// exist are at version 0.
+func (c *scopedCollection) ifVersion(version int) *scopedCollection {
+	conditional := c.withScope(c.scope)
+	conditional.expectedVersion = &version
+	return conditional
+}
+
+func (c *scopedCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	doc, err := c.prepareDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	doc = append(doc, bson.E{Key: versionField, Value: 1})
+	result, err := c.collection.InsertOne(c.bind(ctx), doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	after, err := c.findByID(ctx, result.InsertedID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.record(ctx, model.AuditActionCreate, nil, after); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *scopedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	return c.collection.FindOne(c.bind(ctx), c.filter(filter), opts...)
+}
+
+func (c *scopedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	return c.collection.Find(c.bind(ctx), c.filter(filter), opts...)
+}
+
+func (c *scopedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	return c.collection.CountDocuments(c.bind(ctx), c.filter(filter), opts...)
+}
+
+func (c *scopedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	update, err := c.protectFields(update)
+	if err != nil {
+		return nil, err
+	}
+	before, err := c.snapshot(ctx, c.filter(filter), false)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.UpdateOne(c.bind(ctx), c.filter(filter), update, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		if err := c.versionMismatch(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.recordUpdates(ctx, model.AuditActionUpdate, before, result.UpsertedID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *scopedCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	update, err := c.protectFields(update)
+	if err != nil {
+		return nil, err
+	}
+	before, err := c.snapshot(ctx, c.filter(filter), true)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.UpdateMany(c.bind(ctx), c.filter(filter), update, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		if err := c.versionMismatch(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.recordUpdates(ctx, model.AuditActionUpdate, before, result.UpsertedID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *scopedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	doc, err := c.prepareDocument(replacement)
+	if err != nil {
+		return nil, err
+	}
+	before, err := c.snapshot(ctx, c.filter(filter), false)
+	if err != nil {
+		return nil, err
+	}
+	version := 1
+	if len(before) > 0 {
+		version = versionOf(before[0]) + 1
+	}
+	doc = append(doc, bson.E{Key: versionField, Value: version})
+	result, err := c.collection.ReplaceOne(c.bind(ctx), c.filter(filter), doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		if err := c.versionMismatch(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.recordUpdates(ctx, model.AuditActionUpdate, before, result.UpsertedID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteOne soft deletes the first document matching the filter.
+func (c *scopedCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	return c.softDelete(ctx, filter, false)
+}
+
+// DeleteMany soft deletes the documents matching the filter.
+func (c *scopedCollection) DeleteMany(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	return c.softDelete(ctx, filter, true)
+}
+
+func (c *scopedCollection) softDelete(ctx context.Context, filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	before, err := c.snapshot(ctx, c.filter(filter), many)
+	if err != nil {
+		return nil, err
+	}
+	if len(before) == 0 {
+		if err := c.versionMismatch(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+	deletion := bson.M{deletedAtField: time.Now()}
+	if !c.scope.ActorID.IsZero() {
+		deletion[deletedByField] = c.scope.ActorID
+	}
+	update := bson.M{"$set": deletion, "$inc": bson.M{versionField: 1}}
+	result, err := c.collection.UpdateMany(c.bind(ctx), bson.M{"_id": bson.M{"$in": ids(before)}}, update)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.recordUpdates(ctx, model.AuditActionDelete, before, nil); err != nil {
+		return nil, err
+	}
+	return &mongo.DeleteResult{DeletedCount: result.ModifiedCount}, nil
+}
+
+// Restore brings back the soft deleted documents matching the filter.
+func (c *scopedCollection) Restore(ctx context.Context, filter interface{}) (*mongo.UpdateResult, error) {
+	before, err := c.snapshot(ctx, c.deletedFilter(filter), true)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.UpdateMany(c.bind(ctx), bson.M{"_id": bson.M{"$in": ids(before)}}, bson.M{
+		"$unset": bson.M{deletedAtField: "", deletedByField: ""},
+		"$inc":   bson.M{versionField: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.recordUpdates(ctx, model.AuditActionRestore, before, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Purge permanently removes the documents soft deleted before the given time, along with their versions,
+// and clears the nullified references to them.
+func (c *scopedCollection) Purge(ctx context.Context, deletedBefore time.Time) (*mongo.DeleteResult, error) {
+	before, err := c.snapshot(ctx, c.deletedFilter(bson.M{deletedAtField: bson.M{"$lt": deletedBefore}}), true)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.DeleteMany(c.bind(ctx), bson.M{"_id": bson.M{"$in": ids(before)}})
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range before {
+		if err := c.record(ctx, model.AuditActionPurge, document, nil); err != nil {
+			return nil, err
+		}
+		if err := c.nullifyReferences(ctx, document); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.purgeVersions(ctx, ids(before)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Aggregate runs the pipeline on the documents of the scope only. The documents joined by
+// every $lookup stage are restricted to the tenant as well, so that a join never reaches
+// another tenant; soft deleted documents can still be joined, so that what refers to them
+// keeps showing their details.
+func (c *scopedCollection) Aggregate(ctx context.Context, pipeline bson.A, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	scoped := bson.A{bson.M{"$match": c.filter(bson.M{})}}
+	for _, stage := range pipeline {
+		scoped = append(scoped, stage)
+		if c.scope.AllTenants {
+			continue
+		}
+
+		document, err := toDocument(stage)
+		if err != nil {
+			return nil, err
+		}
+		lookup, ok := valueOf(document, "$lookup").(bson.D)
+		if !ok {
+			continue
+		}
+		as, _ := valueOf(lookup, "as").(string)
+		scoped = append(scoped, bson.M{"$set": bson.M{as: bson.M{"$filter": bson.M{
+			"input": "$" + as,
+			"cond":  bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$this." + tenantField, nil}}, c.tenantValue()}},
+		}}}})
+	}
+	return c.collection.Aggregate(c.bind(ctx), scoped, opts...)
+}
+
+// filter restricts a query filter to the scope.
+func (c *scopedCollection) filter(filter interface{}) interface{} {
+	conditions := bson.A{filter}
+	if !c.scope.AllTenants {
+		conditions = append(conditions, bson.M{tenantField: c.tenantValue()})
+	}
+	if !c.scope.IncludeDeleted {
+		conditions = append(conditions, bson.M{deletedAtField: nil})
+	}
+	if c.expectedVersion != nil {
+		conditions = append(conditions, versionCondition(*c.expectedVersion))
+	}
+	if len(conditions) == 1 {
+		return filter
+	}
+	return bson.M{"$and": conditions}
+}
+
+// versionCondition matches the documents at the given version.
+func versionCondition(version int) bson.M {
+	if version == 0 {
+		return bson.M{versionField: nil}
+	}
+	return bson.M{versionField: version}
+}
+
+// versionMismatch returns ErrVersionMismatch when a conditional write matched nothing because
+// the document is at another version, and a NotFoundError when it is missing.
+func (c *scopedCollection) versionMismatch(ctx context.Context, filter interface{}) error {
+	if c.expectedVersion == nil {
+		return nil
+	}
+	count, err := c.collection.CountDocuments(c.bind(ctx), c.withScope(c.scope).filter(filter))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVersionMismatch
+	}
+	notFound := &NotFoundError{Entity: entityOf(c.collection.Name())}
+	if conditions, ok := filter.(bson.M); ok {
+		if id, ok := conditions["_id"].(primitive.ObjectID); ok {
+			notFound.ID = id.Hex()
+		}
+	}
+	return notFound
+}
+
+// versionOf returns the version of a document, 0 when it was never written since versions exist.
+func versionOf(document bson.M) int {
+	switch version := document[versionField].(type) {
+	case int32:
+		return int(version)
+	case int64:
+		return int(version)
+	}
+	return 0
+}
+
+// deletedFilter restricts a query filter to the soft deleted documents of the scope.
+func (c *scopedCollection) deletedFilter(filter interface{}) interface{} {
+	conditions := bson.A{filter, bson.M{deletedAtField: bson.M{"$ne": nil}}}
+	if !c.scope.AllTenants {
+		conditions = append(conditions, bson.M{tenantField: c.tenantValue()})
+	}
+	return bson.M{"$and": conditions}
+}
+
+// tenantValue is the tenant of the scope, or null for the data which belongs to no organisation.
+func (c *scopedCollection) tenantValue() interface{} {
+	if c.scope.TenantID.IsZero() {
+		return nil
+	}
+	return c.scope.TenantID
+}
+
+// prepareDocument prepares a document about to be written: it is assigned to the tenant of the scope,
+// and cannot be written as deleted nor set its own version or lock.
+func (c *scopedCollection) prepareDocument(document interface{}) (bson.D, error) {
+	doc, err := toDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	doc = removeKey(removeKey(removeKey(removeKey(doc, deletedAtField), deletedByField), versionField), lockField)
+	if c.scope.AllTenants {
+		return doc, nil
+	}
+	doc = removeKey(doc, tenantField)
+	if !c.scope.TenantID.IsZero() {
+		doc = append(doc, bson.E{Key: tenantField, Value: c.scope.TenantID})
+	}
+	return doc, nil
+}
+
+// protectFields removes from the fields an update operator would change the tenant, so that
+// a document never moves to another tenant, the deletion marks, which only Delete and Restore change,
+// and the version, which is incremented instead.
+func (c *scopedCollection) protectFields(update interface{}) (interface{}, error) {
+	doc, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	incremented := false
+	for i, operator := range doc {
+		if fields, ok := operator.Value.(bson.D); ok {
+			fields = removeKey(removeKey(removeKey(fields, deletedAtField), deletedByField), versionField)
+			if !c.scope.AllTenants {
+				fields = removeKey(fields, tenantField)
+			}
+			if operator.Key == "$inc" {
+				fields = append(fields, bson.E{Key: versionField, Value: 1})
+				incremented = true
+			}
+			doc[i].Value = fields
+		}
+	}
+	if !incremented {
+		doc = append(doc, bson.E{Key: "$inc", Value: bson.D{{Key: versionField, Value: 1}}})
+	}
+	return doc, nil
+}
+
+// toDocument converts a document, whatever its Go type, into an ordered BSON document.
+func toDocument(value interface{}) (bson.D, error) {
+	if doc, ok := value.(bson.D); ok {
+		return doc, nil
+	}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+func valueOf(doc bson.D, key string) interface{} {
+	for _, element := range doc {
+		if element.Key == key {
+			return element.Value
+		}
+	}
+	return nil
+}
+
+func removeKey(doc bson.D, key string) bson.D {
+	result := make(bson.D, 0, len(doc))
+	for _, element := range doc {
+		if element.Key != key {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
+// ids returns the IDs of the documents.
+func ids(documents []bson.M) bson.A {
+	result := bson.A{}
+	for _, document := range documents {
+		result = append(result, document["_id"])
+	}
+	return result
+}
+
+// withDeleted returns the same collection, reaching the soft deleted documents as well.
+func (c *scopedCollection) withDeleted() *scopedCollection {
+	scope := c.scope
+	scope.IncludeDeleted = true
+	return c.withScope(scope)
+}
+
+// restoreByID restores the soft deleted document with the given ID, along with the documents
+// deleted with it by cascade, as one unit of work.
+func (c *scopedCollection) restoreByID(id string) error {
+	objectID, err := parseID(entityOf(c.collection.Name()), id)
+	if err != nil {
+		return err
+	}
+	return c.inUnitOfWork(func(scope Scope) error {
+		c := c.withScope(scope)
+		var document struct {
+			DeletedAt time.Time `bson:"deletedAt"`
+		}
+		err := c.collection.FindOne(c.bind(context.Background()), c.deletedFilter(bson.M{"_id": objectID})).Decode(&document)
+		if err == mongo.ErrNoDocuments {
+			return &NotFoundError{Entity: entityOf(c.collection.Name()), ID: id, Message: fmt.Sprintf("no deleted %s with ID %s", entityOf(c.collection.Name()), id)}
+		}
+		if err != nil {
+			return err
+		}
+		return c.restoreCascading(context.Background(), objectID, document.DeletedAt)
+	})
+}
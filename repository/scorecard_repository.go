@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+type ScorecardRepository interface {
+	ComputeScorecard(supplierID string, from time.Time, to time.Time) (*model.Scorecard, error)
+	GetScorecard(supplierID string) (*model.Scorecard, error)
+	RefreshScorecards(window time.Duration) error
+	WithScope(scope Scope) ScorecardRepository
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScorecardMongoRepository is a concrete implementation of ScorecardRepository using MongoDB.
+// Scorecards are computed from the purchases, receipts, returns and contracts of a supplier,
+// and the latest one of every supplier is kept in the scorecards collection.
+type ScorecardMongoRepository struct {
+	scorecardsCollection *scopedCollection
+	suppliersCollection  *scopedCollection
+	locationsCollection  *scopedCollection
+	purchasesCollection  *scopedCollection
+	receiptsCollection   *scopedCollection
+	contractsCollection  *scopedCollection
+}
+
+func NewScorecardMongoRepository(db *mongo.Database) *ScorecardMongoRepository {
+	return &ScorecardMongoRepository{
+		scorecardsCollection: newScopedCollection(db.Collection("scorecards")),
+		suppliersCollection:  newScopedCollection(db.Collection("suppliers")),
+		locationsCollection:  newScopedCollection(db.Collection("locations")),
+		purchasesCollection:  newScopedCollection(db.Collection("purchases")),
+		receiptsCollection:   newScopedCollection(db.Collection("receipts")),
+		contractsCollection:  newScopedCollection(db.Collection("contracts")),
+	}
+}
+
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *ScorecardMongoRepository) WithScope(scope Scope) ScorecardRepository {
+	scoped := *r
+	scoped.scorecardsCollection = r.scorecardsCollection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.locationsCollection = r.locationsCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	scoped.receiptsCollection = r.receiptsCollection.withScope(scope)
+	scoped.contractsCollection = r.contractsCollection.withScope(scope)
+	return &scoped
+}
+
+// ComputeScorecard computes the scorecard of a supplier for the purchases placed between from and to.
+func (r *ScorecardMongoRepository) ComputeScorecard(supplierID string, from time.Time, to time.Time) (*model.Scorecard, error) {
+	objectID, err := parseID("supplier", supplierID)
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.suppliersCollection.CountDocuments(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, &NotFoundError{Entity: "supplier", ID: supplierID}
+	}
+	return r.compute(objectID, from, to)
+}
+
+// GetScorecard retrieves the latest scorecard computed by RefreshScorecards for a supplier.
+func (r *ScorecardMongoRepository) GetScorecard(supplierID string) (*model.Scorecard, error) {
+	objectID, err := parseID("supplier", supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scorecard model.Scorecard
+	err = r.scorecardsCollection.FindOne(context.Background(), bson.M{"supplier": objectID}).Decode(&scorecard)
+	if err != nil {
+		return nil, notFound(err, "scorecard", supplierID)
+	}
+	return &scorecard, nil
+}
+
+// RefreshScorecards recomputes and stores the scorecard of every supplier over the last window.
+func (r *ScorecardMongoRepository) RefreshScorecards(window time.Duration) error {
+	cursor, err := r.suppliersCollection.Find(context.Background(), bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "tenantId": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var suppliers []model.Supplier
+	if err := cursor.All(context.Background(), &suppliers); err != nil {
+		return err
+	}
+
+	to := time.Now()
+	from := to.Add(-window)
+	for _, supplier := range suppliers {
+		scorecard, err := r.compute(supplier.ID, from, to)
+		if err != nil {
+			return err
+		}
+		scorecard.TenantID = supplier.TenantID
+		_, err = r.scorecardsCollection.ReplaceOne(context.Background(), bson.M{"supplier": supplier.ID}, scorecard, options.Replace().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compute builds the scorecard of a supplier:
+//   - the on-time delivery rate is the share of finished deliveries with an expected date whose last receipt
+//     came by that date,
+//   - the return rate is the share of the received units that were returned,
+//   - the price variance is the average relative difference between the price paid and the contract price,
+//   - the lead time is the average number of days between a purchase and its last receipt, over every finished delivery.
+
+func (r *ScorecardMongoRepository) compute(supplierID primitive.ObjectID, from time.Time, to time.Time) (*model.Scorecard, error) {
+	scorecard := &model.Scorecard{SupplierID: supplierID, From: from, To: to, ComputedAt: time.Now()}
+
+	locationIDs, err := r.locationIDs(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"location": bson.M{"$in": locationIDs},
+		"status":   bson.M{"$ne": model.PurchaseStatusDraft},
+		"date":     bson.M{"$gte": from, "$lte": to},
+	}
+	cursor, err := r.purchasesCollection.Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var purchases []model.Purchase
+	if err := cursor.All(context.Background(), &purchases); err != nil {
+		return nil, err
+	}
+	scorecard.Purchases = len(purchases)
+
+	lastReceipts, err := r.lastReceiptDates(purchases)
+	if err != nil {
+		return nil, err
+	}
+	contracts := map[primitive.ObjectID]*model.Contract{}
+
+	var received, returned, delivered, due, onTime, priced int
+	var leadTime time.Duration
+	var variance float64
+	for _, purchase := range purchases {
+		received += purchase.ReceivedQuantity
+		returned += purchase.ReturnedQuantity
+
+		lastReceipt, ok := lastReceipts[purchase.ID]
+		if ok && purchase.DeliveryStatus != model.DeliveryStatusPending && purchase.DeliveryStatus != model.DeliveryStatusPartial {
+			delivered++
+			leadTime += lastReceipt.Sub(purchase.Date)
+			// Only the purchases with an expected date can be late
+			if !purchase.ExpectedDate.IsZero() {
+				due++
+				if !lastReceipt.After(endOfDay(purchase.ExpectedDate)) {
+					onTime++
+				}
+			}
+		}
+
+		if purchase.ContractID.IsZero() {
+			continue
+		}
+		contract, ok := contracts[purchase.ContractID]
+		if !ok {
+			contract = &model.Contract{}
+			err := r.contractsCollection.FindOne(context.Background(), bson.M{"_id": purchase.ContractID}).Decode(contract)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return nil, err
+			}
+			contracts[purchase.ContractID] = contract
+		}
+		if contractPrice, ok := contract.PriceFor(purchase.LocationID); ok && contractPrice != 0 {
+			priced++
+			variance += (purchase.UnitPrice - contractPrice) / contractPrice
+		}
+	}
+
+	if due > 0 {
+		scorecard.OnTimeDeliveryRate = float64(onTime) / float64(due)
+	}
+	if delivered > 0 {
+		scorecard.AverageLeadTimeDays = leadTime.Hours() / 24 / float64(delivered)
+	}
+
+	if received > 0 {
+		scorecard.ReturnRate = float64(returned) / float64(received)
+	}
+	if priced > 0 {
+		scorecard.PriceVariance = variance / float64(priced)
+	}
+	return scorecard, nil
+}
+
+func (r *ScorecardMongoRepository) locationIDs(supplierID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := r.locationsCollection.Find(context.Background(), bson.M{"supplier": supplierID}, options.Find().SetProjection(bson.M{"_id": 1, "tenantId": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var locations []model.Location
+	if err := cursor.All(context.Background(), &locations); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(locations))
+	for _, location := range locations {
+		ids = append(ids, location.ID)
+	}
+	return ids, nil
+}
+
+// lastReceiptDates returns the date of the last receipt of each purchase which received goods.
+func (r *ScorecardMongoRepository) lastReceiptDates(purchases []model.Purchase) (map[primitive.ObjectID]time.Time, error) {
+	purchaseIDs := make([]primitive.ObjectID, 0, len(purchases))
+	for _, purchase := range purchases {
+		purchaseIDs = append(purchaseIDs, purchase.ID)
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"purchase": bson.M{"$in": purchaseIDs}}},
+		bson.M{"$group": bson.M{"_id": "$purchase", "date": bson.M{"$max": "$date"}}},
+	}
+	cursor, err := r.receiptsCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var results []struct {
+		PurchaseID primitive.ObjectID `bson:"_id"`
+		Date       time.Time          `bson:"date"`
+	}
+	if err := cursor.All(context.Background(), &results); err != nil {
+		return nil, err
+	}
+	dates := make(map[primitive.ObjectID]time.Time, len(results))
+	for _, result := range results {
+		dates[result.PurchaseID] = result.Date
+	}
+	return dates, nil
+}
+
+// endOfDay returns the last instant of the day of t, so that deliveries on the expected day count as on time.
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 59, int(time.Second-time.Nanosecond), t.Location())
+}
@@ -1,11 +1,23 @@
 package repository
 
-import "github.com/sandlayth/supplier-api/model"
+import (
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+)
 
 type SupplierRepository interface {
 	CreateSupplier(supplier *model.Supplier) error
 	GetSupplierByID(id string) (*model.Supplier, error)
-	UpdateSupplier(id string, updatedSupplier *model.Supplier) error
-	DeleteSupplier(id string) error
+	UpdateSupplier(id string, updatedSupplier *model.Supplier, version int) error
+	DeleteSupplier(id string, version int) error
+	RestoreSupplier(id string) error
+	ListSupplierVersions(id string) ([]model.Version, error)
+	GetSupplierAsOf(id string, asOf time.Time) (*model.Supplier, error)
+	RevertSupplier(id string, number int, version int) error
 	ListAll() ([]model.Supplier, error)
+	Report() ([]model.SupplierReport, error)
+	ChangeStatus(id string, status string) error
+	UpdateOnboarding(id string, checklist model.OnboardingChecklist, version int) error
+	WithScope(scope Scope) SupplierRepository
 }
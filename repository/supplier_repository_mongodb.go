@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/sandlayth/supplier-api/model"
 	"go.mongodb.org/mongo-driver/bson"
@@ -10,27 +11,42 @@ import (
 )
 
 type SupplierMongoRepository struct {
-	suppliersCollection *mongo.Collection
-	locationsCollection *mongo.Collection
+	suppliersCollection *scopedCollection
+	purchasesCollection *scopedCollection
 }
 
 func NewSupplierMongoRepository(db *mongo.Database) *SupplierMongoRepository {
 	return &SupplierMongoRepository{
-		suppliersCollection: db.Collection("suppliers"),
-		locationsCollection: db.Collection("locations"),
+		suppliersCollection: newScopedCollection(db.Collection("suppliers")),
+		purchasesCollection: newScopedCollection(db.Collection("purchases")),
 	}
 }
 
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *SupplierMongoRepository) WithScope(scope Scope) SupplierRepository {
+	scoped := *r
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	scoped.purchasesCollection = r.purchasesCollection.withScope(scope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *SupplierMongoRepository) unitOfWork(fn func(r *SupplierMongoRepository) error) error {
+	return r.suppliersCollection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*SupplierMongoRepository))
+	})
+}
+
 // GetSupplierByID retrieves a supplier by ID from the database.
 func (r *SupplierMongoRepository) GetSupplierByID(id string) (*model.Supplier, error) {
 	var supplier model.Supplier
-	idSupplier, err := primitive.ObjectIDFromHex(id)
+	idSupplier, err := parseID("supplier", id)
 	if err != nil {
 		return nil, err
 	}
 	err = r.suppliersCollection.FindOne(context.Background(), bson.M{"_id": idSupplier}).Decode(&supplier)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "supplier", id)
 	}
 	return &supplier, nil
 }
@@ -53,32 +69,205 @@ func (r *SupplierMongoRepository) ListAll() ([]model.Supplier, error) {
 
 // CreateSupplier adds a new supplier to the database.
 func (r *SupplierMongoRepository) CreateSupplier(supplier *model.Supplier) error {
+	if err := validateSupplier(supplier); err != nil {
+		return err
+	}
+	// New suppliers go through onboarding before being activated
+	supplier.Status = model.SupplierStatusOnboarding
+	supplier.Onboarding = model.OnboardingChecklist{}
 	_, err := r.suppliersCollection.InsertOne(context.Background(), supplier)
 	return err
 }
 
 // UpdateSupplier updates an existing supplier in the database.
-func (r *SupplierMongoRepository) UpdateSupplier(id string, updatedSupplier *model.Supplier) error {
-	idSupplier, err := primitive.ObjectIDFromHex(id)
+func (r *SupplierMongoRepository) UpdateSupplier(id string, updatedSupplier *model.Supplier, version int) error {
+	return r.unitOfWork(func(r *SupplierMongoRepository) error {
+		return r.updateSupplier(id, updatedSupplier, version)
+	})
+}
+
+func (r *SupplierMongoRepository) updateSupplier(id string, updatedSupplier *model.Supplier, version int) error {
+	idSupplier, err := parseID("supplier", id)
 	if err != nil {
 		return err
 	}
-	_, err = r.suppliersCollection.UpdateOne(context.Background(), bson.M{"_id": idSupplier}, bson.M{"$set": updatedSupplier})
+	if err := validateSupplier(updatedSupplier); err != nil {
+		return err
+	}
+	// The lifecycle only changes through status transitions
+	currentSupplier, err := r.GetSupplierByID(id)
+	if err != nil {
+		return err
+	}
+	updatedSupplier.Status = currentSupplier.Status
+	updatedSupplier.Onboarding = currentSupplier.Onboarding
+	_, err = r.suppliersCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": idSupplier}, bson.M{"$set": updatedSupplier})
 	return err
 }
 
-// DeleteSupplier removes a supplier from the database by ID.
-func (r *SupplierMongoRepository) DeleteSupplier(id string) error {
-	idSupplier, err := primitive.ObjectIDFromHex(id)
+// DeleteSupplier removes a supplier from the database by ID, along with its locations and contracts.
+// A supplier still referenced by users, invoices, RFQs or purchases cannot be deleted.
+func (r *SupplierMongoRepository) DeleteSupplier(id string, version int) error {
+	idSupplier, err := parseID("supplier", id)
+	if err != nil {
+		return err
+	}
+	return r.suppliersCollection.deleteByID(context.Background(), idSupplier, version)
+}
+
+// RestoreSupplier brings back a deleted supplier, along with the locations and contracts deleted with it.
+func (r *SupplierMongoRepository) RestoreSupplier(id string) error {
+	return r.suppliersCollection.restoreByID(id)
+}
+
+// ListSupplierVersions retrieves the versions of a supplier, oldest first.
+func (r *SupplierMongoRepository) ListSupplierVersions(id string) ([]model.Version, error) {
+	objectID, err := parseID("supplier", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.suppliersCollection.versions(context.Background(), objectID, func() interface{} { return &model.Supplier{} })
+}
+
+// GetSupplierAsOf retrieves the state a supplier was in at the given time.
+func (r *SupplierMongoRepository) GetSupplierAsOf(id string, asOf time.Time) (*model.Supplier, error) {
+	objectID, err := parseID("supplier", id)
+	if err != nil {
+		return nil, err
+	}
+	var supplier model.Supplier
+	err = r.suppliersCollection.versionAsOf(context.Background(), objectID, asOf, &supplier)
+	if err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// RevertSupplier brings a supplier back to one of its versions. The version is applied like an update:
+// the status and onboarding checklist, which change through their own transitions, are kept.
+func (r *SupplierMongoRepository) RevertSupplier(id string, number int, version int) error {
+	return r.unitOfWork(func(r *SupplierMongoRepository) error {
+		return r.revertSupplier(id, number, version)
+	})
+}
+
+func (r *SupplierMongoRepository) revertSupplier(id string, number int, version int) error {
+	objectID, err := parseID("supplier", id)
 	if err != nil {
 		return err
 	}
-	// First delete all locations related to the Supplier
-	_, err = r.locationsCollection.DeleteMany(context.Background(), bson.M{"supplier": idSupplier})
+	var supplier model.Supplier
+	err = r.suppliersCollection.version(context.Background(), objectID, number, &supplier)
 	if err != nil {
 		return err
 	}
-	// Then delete the Supplier
-	_, err = r.suppliersCollection.DeleteOne(context.Background(), bson.M{"_id": idSupplier})
+	supplier.ID = primitive.NilObjectID
+	return r.updateSupplier(id, &supplier, version)
+}
+
+// supplierTransitions lists the statuses a supplier can move to from each status.
+var supplierTransitions = map[string][]string{
+	model.SupplierStatusOnboarding: {model.SupplierStatusActive, model.SupplierStatusBlocked},
+	model.SupplierStatusActive:     {model.SupplierStatusSuspended, model.SupplierStatusBlocked},
+	model.SupplierStatusSuspended:  {model.SupplierStatusActive, model.SupplierStatusBlocked},
+	model.SupplierStatusBlocked:    {model.SupplierStatusOnboarding},
+}
+
+// ChangeStatus moves a supplier through its lifecycle. A supplier can only be activated
+// once its onboarding checklist is complete.
+func (r *SupplierMongoRepository) ChangeStatus(id string, status string) error {
+	return r.unitOfWork(func(r *SupplierMongoRepository) error {
+		return r.changeStatus(id, status)
+	})
+}
+
+func (r *SupplierMongoRepository) changeStatus(id string, status string) error {
+	supplier, err := r.GetSupplierByID(id)
+	if err != nil {
+		return err
+	}
+	current := supplier.Status
+	if current == "" {
+		current = model.SupplierStatusActive
+	}
+
+	allowed := false
+	for _, next := range supplierTransitions[current] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return conflict("supplier cannot go from %s to %s", current, status)
+	}
+	if status == model.SupplierStatusActive && !supplier.Onboarding.Complete() {
+		return conflict("supplier onboarding checklist is not complete")
+	}
+
+	_, err = r.suppliersCollection.UpdateOne(context.Background(), bson.M{"_id": supplier.ID}, bson.M{"$set": bson.M{"status": status}})
 	return err
 }
+
+// UpdateOnboarding updates the onboarding checklist of a supplier.
+func (r *SupplierMongoRepository) UpdateOnboarding(id string, checklist model.OnboardingChecklist, version int) error {
+	idSupplier, err := parseID("supplier", id)
+	if err != nil {
+		return err
+	}
+	result, err := r.suppliersCollection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": idSupplier}, bson.M{"$set": bson.M{"onboarding": checklist}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return &NotFoundError{Entity: "supplier", ID: id}
+	}
+	return nil
+}
+
+// Report aggregates the confirmed purchases of every supplier, with their fill rate and net spend.
+func (r *SupplierMongoRepository) Report() ([]model.SupplierReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := bson.A{
+		bson.D{{"$match", bson.D{{"status", bson.D{{"$ne", model.PurchaseStatusDraft}}}}}},
+		bson.D{{"$lookup", bson.D{{"from", "locations"}, {"localField", "location"}, {"foreignField", "_id"}, {"as", "locationInfo"}}}},
+		bson.D{{"$unwind", "$locationInfo"}},
+		bson.D{{"$group", bson.D{
+			{"_id", "$locationInfo.supplier"},
+			{"purchases", bson.D{{"$sum", 1}}},
+			{"orderedQuantity", bson.D{{"$sum", "$quantity"}}},
+			{"filledQuantity", bson.D{{"$sum", bson.D{{"$min", bson.A{"$receivedQuantity", "$quantity"}}}}}},
+			{"spend", bson.D{{"$sum", "$totalPrice"}}},
+			{"credits", bson.D{{"$sum", "$creditedAmount"}}},
+		}}},
+		bson.D{{"$lookup", bson.D{{"from", "suppliers"}, {"localField", "_id"}, {"foreignField", "_id"}, {"as", "supplierInfo"}}}},
+		bson.D{{"$unwind", "$supplierInfo"}},
+		bson.D{{"$project", bson.D{
+			{"_id", 1},
+			{"supplierName", "$supplierInfo.name"},
+			{"purchases", 1},
+			{"orderedQuantity", 1},
+			{"filledQuantity", 1},
+			{"fillRate", bson.D{{"$cond", bson.A{
+				bson.D{{"$gt", bson.A{"$orderedQuantity", 0}}},
+				bson.D{{"$divide", bson.A{"$filledQuantity", "$orderedQuantity"}}},
+				0,
+			}}}},
+			{"spend", 1},
+			{"credits", 1},
+			{"netSpend", bson.D{{"$subtract", bson.A{"$spend", "$credits"}}}},
+		}}},
+		bson.D{{"$sort", bson.D{{"supplierName", 1}}}},
+	}
+
+	cursor, err := r.purchasesCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []model.SupplierReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"fmt"
+	"math/big"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+// taxIDFormats lists the VAT or tax number formats of the countries we work with, by ISO country code.
+// The tax number of a supplier from another country only has to look like an identifier.
+var taxIDFormats = map[string]*regexp.Regexp{
+	"BE": regexp.MustCompile(`^BE[01]\d{9}$`),
+	"DE": regexp.MustCompile(`^DE\d{9}$`),
+	"ES": regexp.MustCompile(`^ES[A-Z0-9]\d{7}[A-Z0-9]$`),
+	"FR": regexp.MustCompile(`^FR[A-Z0-9]{2}\d{9}$`),
+	"GB": regexp.MustCompile(`^GB(\d{9}|\d{12})$`),
+	"IT": regexp.MustCompile(`^IT\d{11}$`),
+	"LU": regexp.MustCompile(`^LU\d{8}$`),
+	"NL": regexp.MustCompile(`^NL\d{9}B\d{2}$`),
+	"US": regexp.MustCompile(`^\d{2}-\d{7}$`),
+}
+
+var genericTaxIDFormat = regexp.MustCompile(`^[A-Z0-9-]{4,20}$`)
+
+var bicFormat = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+var contactRoles = map[string]bool{
+	model.ContactRoleSales:   true,
+	model.ContactRoleBilling: true,
+	model.ContactRoleSupport: true,
+}
+
+// validateSupplier checks the supplier profile: its contacts, tax number and bank details.
+// Tax numbers and bank identifiers are normalised to upper case without spaces.
+func validateSupplier(supplier *model.Supplier) error {
+	if len(strings.TrimSpace(supplier.Name)) == 0 {
+		return invalid("supplier", "name", "is required")
+	}
+	if supplier.Email != "" {
+		if _, e := mail.ParseAddress(supplier.Email); e != nil {
+			return invalid("supplier", "email", "is not a valid email address")
+		}
+	}
+	for i, contact := range supplier.Contacts {
+		field := fmt.Sprintf("contacts[%d]", i)
+		if len(strings.TrimSpace(contact.Name)) == 0 {
+			return invalid("supplier", field+".name", "is required")
+		}
+		if !contactRoles[contact.Role] {
+			return invalid("supplier", field+".role", "is not a known contact role")
+		}
+		if contact.Email != "" {
+			if _, e := mail.ParseAddress(contact.Email); e != nil {
+				return invalid("supplier", field+".email", "is not a valid email address")
+			}
+		}
+	}
+
+	supplier.TaxID = normalise(supplier.TaxID)
+	if supplier.TaxID != "" {
+		format, ok := taxIDFormats[strings.ToUpper(supplier.BillingAddress.Country)]
+		if !ok {
+			format = genericTaxIDFormat
+		}
+		if !format.MatchString(supplier.TaxID) {
+			return invalid("supplier", "taxId", "is not a valid tax number")
+		}
+	}
+
+	supplier.BankDetails.IBAN = normalise(supplier.BankDetails.IBAN)
+	if supplier.BankDetails.IBAN != "" && !validIBAN(supplier.BankDetails.IBAN) {
+		return invalid("supplier", "bankDetails.iban", "is not a valid IBAN")
+	}
+	supplier.BankDetails.BIC = normalise(supplier.BankDetails.BIC)
+	if supplier.BankDetails.BIC != "" && !bicFormat.MatchString(supplier.BankDetails.BIC) {
+		return invalid("supplier", "bankDetails.bic", "is not a valid BIC")
+	}
+	return nil
+}
+
+// validIBAN checks the structure and the ISO 7064 mod 97 checksum of an IBAN.
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	// Move the country code and check digits to the end, then replace letters by numbers (A = 10)
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+		default:
+			return false
+		}
+	}
+	number, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(number, big.NewInt(97)).Int64() == 1
+}
+
+func normalise(identifier string) string {
+	return strings.ToUpper(strings.ReplaceAll(identifier, " ", ""))
+}
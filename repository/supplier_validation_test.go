@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sandlayth/supplier-api/model"
+)
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"DE89370400440532013000", true},
+		{"FR1420041010050500013M02606", true},
+		{"GB82WEST12345698765433", false},
+		{"GB83WEST12345698765432", false},
+		{"GB82WEST1234", false},
+		{"GB82WEST12345698765432123456789012345", false},
+		{"GB82-WEST12345698765432", false},
+		{"gb82west12345698765432", false},
+	}
+	for _, test := range tests {
+		if got := validIBAN(test.iban); got != test.want {
+			t.Errorf("validIBAN(%q) = %v, want %v", test.iban, got, test.want)
+		}
+	}
+}
+
+func TestValidateSupplier(t *testing.T) {
+	tests := []struct {
+		name     string
+		supplier model.Supplier
+		field    string
+	}{
+		{"tax ID of the country", model.Supplier{Name: "Acme", BillingAddress: model.Address{Country: "FR"}, TaxID: "FR12345678901"}, ""},
+		{"tax ID of another country", model.Supplier{Name: "Acme", BillingAddress: model.Address{Country: "FR"}, TaxID: "DE123456789"}, "taxId"},
+		{"tax ID of an unlisted country", model.Supplier{Name: "Acme", BillingAddress: model.Address{Country: "CH"}, TaxID: "CHE.123"}, "taxId"},
+		{"generic tax ID", model.Supplier{Name: "Acme", BillingAddress: model.Address{Country: "CH"}, TaxID: "CHE-123456789"}, ""},
+		{"US tax ID", model.Supplier{Name: "Acme", BillingAddress: model.Address{Country: "us"}, TaxID: "12-3456789"}, ""},
+		{"IBAN with spaces", model.Supplier{Name: "Acme", BankDetails: model.BankDetails{IBAN: "fr14 2004 1010 0505 0001 3M02 606"}}, ""},
+		{"IBAN with a wrong checksum", model.Supplier{Name: "Acme", BankDetails: model.BankDetails{IBAN: "FR1520041010050500013M02606"}}, "bankDetails.iban"},
+		{"BIC", model.Supplier{Name: "Acme", BankDetails: model.BankDetails{BIC: "BNPAFRPP"}}, ""},
+		{"BIC with branch", model.Supplier{Name: "Acme", BankDetails: model.BankDetails{BIC: "BNPAFRPPXXX"}}, ""},
+		{"BIC too short", model.Supplier{Name: "Acme", BankDetails: model.BankDetails{BIC: "BNPAFR"}}, "bankDetails.bic"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSupplier(&test.supplier)
+			if test.field == "" {
+				if err != nil {
+					t.Fatalf("validateSupplier() = %v, want no error", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("validateSupplier() = %v, want a ValidationError", err)
+			}
+			if got := validationErr.Fields[0].Field; got != test.field {
+				t.Errorf("validateSupplier() reported %s, want %s", got, test.field)
+			}
+		})
+	}
+}
+
+func TestValidateSupplierNormalises(t *testing.T) {
+	supplier := &model.Supplier{
+		Name:           "Acme",
+		BillingAddress: model.Address{Country: "FR"},
+		TaxID:          "fr 12 345678901",
+		BankDetails:    model.BankDetails{IBAN: "fr14 2004 1010 0505 0001 3m02 606", BIC: "bnpa frpp"},
+	}
+	if err := validateSupplier(supplier); err != nil {
+		t.Fatalf("validateSupplier() = %v, want no error", err)
+	}
+	if supplier.TaxID != "FR12345678901" {
+		t.Errorf("TaxID = %q, want FR12345678901", supplier.TaxID)
+	}
+	if supplier.BankDetails.IBAN != "FR1420041010050500013M02606" {
+		t.Errorf("IBAN = %q, want FR1420041010050500013M02606", supplier.BankDetails.IBAN)
+	}
+	if supplier.BankDetails.BIC != "BNPAFRPP" {
+		t.Errorf("BIC = %q, want BNPAFRPP", supplier.BankDetails.BIC)
+	}
+}
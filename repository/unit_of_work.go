@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnitOfWork runs an operation which reads and writes several documents, possibly through several
+// repositories, so that its writes are applied all together or not at all, and that no concurrent
+// write slips in between its reads and its writes.
+type UnitOfWork interface {
+	// Do runs fn with the scope bound to the unit of work: the reads and writes made through the
+	// repositories given the scope fn receives take part in it. fn can be run again when the unit
+	// of work fails for a transient reason, so it must not have other side effects.
+	Do(scope Scope, fn func(scope Scope) error) error
+}
+
+// ErrTransactionsUnsupported is returned for the operations which must be atomic when MongoDB
+// does not run as a replica set.
+var ErrTransactionsUnsupported = errors.New("atomic operations need MongoDB to run as a replica set")
+
+// MongoUnitOfWork is a unit of work backed by a MongoDB session and transaction. Transactions
+// need MongoDB to run as a replica set; on a standalone server, the operations are run without one.
+type MongoUnitOfWork struct {
+	client *mongo.Client
+}
+
+func NewMongoUnitOfWork(db *mongo.Database) *MongoUnitOfWork {
+	return &MongoUnitOfWork{client: db.Client()}
+}
+
+// Do runs fn in a transaction, committed if fn succeeds and rolled back otherwise. A scope already
+// bound to a transaction is kept, so that an operation run inside another one joins it.
+func (u *MongoUnitOfWork) Do(scope Scope, fn func(scope Scope) error) error {
+	if scope.transaction != nil || !supportsTransactions(u.client) {
+		return fn(scope)
+	}
+	return u.transaction(scope, fn)
+}
+
+func (u *MongoUnitOfWork) transaction(scope Scope, fn func(scope Scope) error) error {
+	session, err := u.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(context.Background(), func(transaction mongo.SessionContext) (interface{}, error) {
+		bound := scope
+		bound.transaction = transaction
+		return nil, fn(bound)
+	})
+	return err
+}
+
+// transactionSupport caches, by client, whether the deployment supports transactions.
+var transactionSupport sync.Map
+
+// supportsTransactions tells whether the deployment the client is connected to runs as a replica set
+// or a sharded cluster. It is checked once per client, and a warning is logged when it does not.
+func supportsTransactions(client *mongo.Client) bool {
+	if supported, ok := transactionSupport.Load(client); ok {
+		return supported.(bool)
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		log.Printf("Checking the support of transactions failed: %v\n", err)
+		return false
+	}
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if _, loaded := transactionSupport.LoadOrStore(client, supported); !loaded && !supported {
+		log.Println("MongoDB does not run as a replica set, operations spanning several documents are not transactional")
+	}
+	return supported
+}
+
+// inUnitOfWork runs fn as one unit of work, with the scope of the collection bound to it.
+func (c *scopedCollection) inUnitOfWork(fn func(scope Scope) error) error {
+	return NewMongoUnitOfWork(c.collection.Database()).Do(c.scope, fn)
+}
+
+// inTransaction runs fn in a transaction, with the scope of the collection bound to it, and fails
+// with ErrTransactionsUnsupported rather than running fn without one.
+func (c *scopedCollection) inTransaction(fn func(scope Scope) error) error {
+	if c.scope.transaction == nil && !supportsTransactions(c.collection.Database().Client()) {
+		return ErrTransactionsUnsupported
+	}
+	return c.inUnitOfWork(fn)
+}
+
+// lockField is written by lock, to make a unit of work conflict with the concurrent writes of a document.
+const lockField = "_lock"
+
+// lock marks a document read by a unit of work, such as the one a new document is about to refer to,
+// so that a concurrent transaction changing or deleting it conflicts with the unit of work instead of
+// going unnoticed. Outside of a transaction it does nothing. The mark is neither audited nor versioned.
+func (c *scopedCollection) lock(ctx context.Context, id primitive.ObjectID) error {
+	if c.scope.transaction == nil {
+		return nil
+	}
+	_, err := c.collection.UpdateOne(c.bind(ctx), bson.M{"_id": id}, bson.M{"$set": bson.M{lockField: primitive.NewObjectID()}})
+	return err
+}
@@ -6,11 +6,16 @@ type UserRepository interface {
 	CreateUser(user *model.User) error
 	GetUserByID(id string) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
-	UpdateUser(id string, updatedUser *model.User) error
-	DeleteUser(id string) error
+	UpdateUser(id string, updatedUser *model.User, version int) error
+	DeleteUser(id string, version int) error
+	RestoreUser(id string) error
 	ListAll() (*[]model.User, error)
 	GetTokens(user *model.User) (string, string, error)
 	RenewTokens(userID string, refreshToken string) (string, string, error)
     ValidateUserCredentials(user *model.User) error
+	ChangePassword(id string, currentPassword string, newPassword string) (*model.User, error)
+	CheckSession(claims *model.Claims) (bool, error)
+
 	//RevokeToken(userID string, refreshToken string) error
+	WithScope(scope Scope) UserRepository
 }
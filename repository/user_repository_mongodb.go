@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/mail"
 	"strings"
 
@@ -16,17 +17,50 @@ import (
 )
 
 type UserMongoRepository struct {
-	collection *mongo.Collection
+	collection              *scopedCollection
+	suppliersCollection     *scopedCollection
+	organisationsCollection *scopedCollection
 }
 
 func NewUserMongoRepository(db *mongo.Database) *UserMongoRepository {
 	return &UserMongoRepository{
-		collection: db.Collection("users"),
+		collection:              newScopedCollection(db.Collection("users")),
+		suppliersCollection:     newScopedCollection(db.Collection("suppliers")),
+		organisationsCollection: newScopedCollection(db.Collection("organisations")),
 	}
 }
 
+// WithScope returns a copy of the repository restricted to the scope.
+func (r *UserMongoRepository) WithScope(scope Scope) UserRepository {
+	scoped := *r
+	scoped.collection = r.collection.withScope(scope)
+	scoped.suppliersCollection = r.suppliersCollection.withScope(scope)
+	// Organisations are the tenants themselves, so they are never restricted to a tenant
+	organisationScope := scope
+	organisationScope.TenantID = primitive.NilObjectID
+	organisationScope.AllTenants = true
+	scoped.organisationsCollection = r.organisationsCollection.withScope(organisationScope)
+	return &scoped
+}
+
+// unitOfWork runs fn as one unit of work, with a copy of the repository taking part in it.
+func (r *UserMongoRepository) unitOfWork(fn func(r *UserMongoRepository) error) error {
+	return r.collection.inUnitOfWork(func(scope Scope) error {
+		return fn(r.WithScope(scope).(*UserMongoRepository))
+	})
+}
+
 // CreateUser adds a new user to the database.
 func (r *UserMongoRepository) CreateUser(user *model.User) error {
+	// The password is hashed on the way, so a retried unit of work starts again from the one given
+	password := user.Password
+	return r.unitOfWork(func(r *UserMongoRepository) error {
+		user.Password = password
+		return r.createUser(user)
+	})
+}
+
+func (r *UserMongoRepository) createUser(user *model.User) error {
 	if err := r.validateUser(user); err != nil {
 		return err
 	}
@@ -36,18 +70,21 @@ func (r *UserMongoRepository) CreateUser(user *model.User) error {
 	}
 	user.Password = string(hashedPassword)
 	result, err := r.collection.InsertOne(context.Background(), user)
+	if err != nil {
+		return err
+	}
 	// Update the purchase with the new ID
 	insertedID, ok := result.InsertedID.(primitive.ObjectID)
 	if !ok {
 		return errors.New("inserted ID is not a primitive.ObjectID")
 	}
 	user.ID = insertedID
-	return err
+	return nil
 }
 
 // GetUserByID retrieves a user by ID from the database.
 func (r *UserMongoRepository) GetUserByID(id string) (*model.User, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID("user", id)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +92,7 @@ func (r *UserMongoRepository) GetUserByID(id string) (*model.User, error) {
 	var user model.User
 	err = r.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "user", id)
 	}
 	return &user, nil
 }
@@ -64,40 +101,73 @@ func (r *UserMongoRepository) GetUserByID(id string) (*model.User, error) {
 func (r *UserMongoRepository) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
 	err := r.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, &NotFoundError{Entity: "user", Message: fmt.Sprintf("user with email %s does not exist", email)}
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// UpdateUser updates an existing user in the database.
-func (r *UserMongoRepository) UpdateUser(id string, updatedUser *model.User) error {
-	if err := r.validateUser(updatedUser); err != nil {
+// UpdateUser updates an existing user in the database. The password is only hashed when a new one is given:
+// an empty password, or the hash of the current one, keeps the current password.
+func (r *UserMongoRepository) UpdateUser(id string, updatedUser *model.User, version int) error {
+	// The password is hashed on the way, so a retried unit of work starts again from the one given
+	password := updatedUser.Password
+	return r.unitOfWork(func(r *UserMongoRepository) error {
+		updatedUser.Password = password
+		return r.updateUser(id, updatedUser, version)
+	})
+}
+
+func (r *UserMongoRepository) updateUser(id string, updatedUser *model.User, version int) error {
+	currentUser, err := r.GetUserByID(id)
+	if err != nil {
 		return err
 	}
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
+	newPassword := updatedUser.Password != "" && updatedUser.Password != currentUser.Password
+	if !newPassword {
+		updatedUser.Password = currentUser.Password
+	}
+	// A new password closes the sessions opened with the former one
+	updatedUser.SessionVersion = currentUser.SessionVersion
+	if newPassword {
+		updatedUser.SessionVersion++
+	}
+	if err := r.validateUser(updatedUser); err != nil {
 		return err
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updatedUser.Password), bcrypt.MinCost)
+	if newPassword {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updatedUser.Password), bcrypt.MinCost)
+		if err != nil {
+			return err
+		}
+		updatedUser.Password = string(hashedPassword)
+	}
+	_, err = r.collection.ifVersion(version).UpdateOne(context.Background(), bson.M{"_id": currentUser.ID}, bson.M{"$set": updatedUser})
 	if err != nil {
 		return err
 	}
-	updatedUser.Password = string(hashedPassword)
-	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": updatedUser})
-	updatedUser.ID = objectID
-	return err
+	updatedUser.ID = currentUser.ID
+	updatedUser.Version = version + 1
+	return nil
 }
 
-// DeleteUser removes a user from the database by ID.
-func (r *UserMongoRepository) DeleteUser(id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// DeleteUser removes a user from the database by ID. The purchases, receipts and returns
+// of the user lose their reference to it once it is purged, but keep its email as the user name.
+func (r *UserMongoRepository) DeleteUser(id string, version int) error {
+	objectID, err := parseID("user", id)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.collection.DeleteOne(context.Background(), bson.M{"_id": objectID})
-	return err
+	return r.collection.deleteByID(context.Background(), objectID, version)
+}
+
+// RestoreUser brings back a deleted user.
+func (r *UserMongoRepository) RestoreUser(id string) error {
+	return r.collection.restoreByID(id)
 }
 
 // ListUsers retrieves a list of all users from the database.
@@ -154,13 +224,17 @@ func (r *UserMongoRepository) RenewTokens(userID string, refreshToken string) (s
 	// Verify the refresh token and extract claims
 	claims, needsRefresh, err := helper.VerifyToken(refreshToken)
 	if err != nil {
-		return "", "", err
+		return "", "", &ForbiddenError{Message: "invalid refresh token"}
 	}
 	if userID != claims.UserID.Hex() {
-		return "", "", errors.New("invalid user ID in refresh token")
+		return "", "", &ForbiddenError{Message: "the refresh token belongs to another user"}
+	}
+	if claims.SessionVersion != dbUser.SessionVersion {
+		return "", "", &ForbiddenError{Message: "the session was closed, log in again"}
 	}
 	if needsRefresh {
-		return r.GetTokens(dbUser)
+		newRefreshToken, newAccessToken, err := r.GetTokens(dbUser)
+		return newAccessToken, newRefreshToken, err
 	}
 	// Return the original refresh token
 	accessToken, err := helper.GenerateAccessToken(dbUser)
@@ -171,30 +245,120 @@ func (r *UserMongoRepository) RenewTokens(userID string, refreshToken string) (s
 
 }
 
+// ChangePassword replaces the password of a user once its current password is confirmed, and closes the other
+// sessions of the user: the tokens issued before are refused from then on. The user returned carries the new
+// session version, for the tokens of the current session to be issued again.
+func (r *UserMongoRepository) ChangePassword(id string, currentPassword string, newPassword string) (*model.User, error) {
+	var user *model.User
+	err := r.unitOfWork(func(r *UserMongoRepository) error {
+		var err error
+		user, err = r.changePassword(id, currentPassword, newPassword)
+		return err
+	})
+	return user, err
+}
+
+func (r *UserMongoRepository) changePassword(id string, currentPassword string, newPassword string) (*model.User, error) {
+	user, err := r.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)) != nil {
+		return nil, invalid("password change", "currentPassword", "is not the current password")
+	}
+	if newPassword == currentPassword {
+		return nil, invalid("password change", "newPassword", "must differ from the current password")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.MinCost)
+	if err != nil {
+		return nil, err
+	}
+	update := bson.M{"$set": bson.M{"password": string(hashedPassword)}, "$inc": bson.M{"sessionVersion": 1}}
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": user.ID}, update)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = string(hashedPassword)
+	user.SessionVersion++
+	user.Version++
+	return user, nil
+}
+
+// CheckSession tells whether the session the claims of a token were issued for is still open:
+// the user was not deleted and did not change its password since.
+func (r *UserMongoRepository) CheckSession(claims *model.Claims) (bool, error) {
+	var user model.User
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": claims.UserID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.SessionVersion == claims.SessionVersion, nil
+}
+
+// ErrInvalidCredentials is returned when no user has the email and password given to log in.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
 func (r *UserMongoRepository) ValidateUserCredentials(user *model.User) error {
 	dbUser, err := r.GetUserByEmail(user.Email)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return ErrInvalidCredentials
+	}
 	if err != nil {
 		return err
 	}
-	return bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(user.Password))
+	if bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(user.Password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
 }
 
 func (r *UserMongoRepository) validateUser(user *model.User) error {
-	err := "Error when validating user input: "
 	if _, e := mail.ParseAddress(user.Email); e != nil {
-		return errors.New(err + "invalid email")
+		return invalid("user", "email", "is not a valid email address")
 	}
 	if len(strings.TrimSpace(user.FirstName)) == 0 {
-		return errors.New(err + "invalid firstName field")
+		return invalid("user", "first_name", "is required")
 	}
 	if len(strings.TrimSpace(user.LastName)) == 0 {
-		return errors.New(err + "invalid lastName field")
+		return invalid("user", "last_name", "is required")
 	}
 	if len(strings.TrimSpace(user.Password)) == 0 {
-		return errors.New(err + "invalid password field")
+		return invalid("user", "password", "is required")
 	}
-	if user.Role != "manager" && user.Role != "admin" {
-		return errors.New(err + "invalid role field")
+	if user.Role != "manager" && user.Role != "admin" && user.Role != "supplier" && user.Role != "superadmin" {
+		return invalid("user", "role", "is not a known role")
 	}
-	return nil
+	// Super-admins manage every organisation and belong to none
+	if user.Role == "superadmin" {
+		user.TenantID = primitive.NilObjectID
+	}
+	if !user.TenantID.IsZero() {
+		count, e := r.organisationsCollection.CountDocuments(context.Background(), bson.M{"_id": user.TenantID})
+		if e != nil {
+			return e
+		}
+		if count == 0 {
+			return invalid("user", "tenant", "is not an existing organisation")
+		}
+		if e := r.organisationsCollection.lock(context.Background(), user.TenantID); e != nil {
+			return e
+		}
+	}
+	// Supplier users are bound to exactly one supplier, the other users to none
+	if user.Role != "supplier" {
+		user.SupplierID = primitive.NilObjectID
+		return nil
+	}
+	count, e := r.suppliersCollection.CountDocuments(context.Background(), bson.M{"_id": user.SupplierID})
+	if e != nil {
+		return e
+	}
+	if count == 0 {
+		return invalid("user", "supplier", "is not an existing supplier")
+	}
+	return r.suppliersCollection.lock(context.Background(), user.SupplierID)
 }
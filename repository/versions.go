@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sandlayth/supplier-api/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// versionsCollection is the collection the versions of the versioned entities are kept in.
+const versionsCollection = "versions"
+
+// versionedCollections are the collections whose documents keep a version for every write.
+var versionedCollections = map[string]bool{
+	"suppliers": true,
+	"locations": true,
+	"purchases": true,
+}
+
+// storedVersion is a version as it is stored, its document being decoded once its entity type is known.
+type storedVersion struct {
+	model.Version `bson:",inline"`
+	Document      bson.Raw `bson:"document"`
+}
+
+// recordVersion keeps the state of a document after the write recorded by the audit entry,
+// numbered after the version of the document. Like the audit, a failure fails the write.
+func (c *scopedCollection) recordVersion(ctx context.Context, entry model.AuditEntry, document bson.M) error {
+	entityID, _ := document["_id"].(primitive.ObjectID)
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("version of %s %v failed: %w", entry.Entity, entityID, err)
+	}
+
+	version := storedVersion{
+		Version: model.Version{
+			TenantID:  entry.TenantID,
+			Entity:    entry.Entity,
+			EntityID:  entityID,
+			Number:    versionOf(document),
+			Action:    entry.Action,
+			ActorID:   entry.ActorID,
+			Timestamp: entry.Timestamp,
+		},
+		Document: raw,
+	}
+	_, err = c.collection.Database().Collection(versionsCollection).InsertOne(c.bind(ctx), version)
+	if err != nil {
+		return fmt.Errorf("version of %s %v failed: %w", entry.Entity, entityID, err)
+	}
+	return nil
+}
+
+// versions lists the versions of a document, oldest first. Their documents are decoded
+// into the values returned by newDocument.
+func (c *scopedCollection) versions(ctx context.Context, id primitive.ObjectID, newDocument func() interface{}) ([]model.Version, error) {
+	opts := options.Find().SetSort(bson.M{"number": 1})
+	cursor, err := c.sibling(versionsCollection).Find(ctx, bson.M{"entity": c.collection.Name(), "entityId": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var stored []storedVersion
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+	versions := make([]model.Version, 0, len(stored))
+	for _, version := range stored {
+		document := newDocument()
+		if err := bson.Unmarshal(version.Document, document); err != nil {
+			return nil, err
+		}
+		version.Version.Document = document
+		versions = append(versions, version.Version)
+	}
+	return versions, nil
+}
+
+// versionAsOf decodes into document the state a document was in at the given time.
+func (c *scopedCollection) versionAsOf(ctx context.Context, id primitive.ObjectID, asOf time.Time, document interface{}) error {
+	filter := bson.M{"entity": c.collection.Name(), "entityId": id, "timestamp": bson.M{"$lte": asOf}}
+	opts := options.FindOne().SetSort(bson.M{"number": -1})
+	var version storedVersion
+	err := c.sibling(versionsCollection).FindOne(ctx, filter, opts).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return &NotFoundError{Entity: entityOf(c.collection.Name()), ID: id.Hex(), Message: fmt.Sprintf("no version of %s %s as of %s", entityOf(c.collection.Name()), id.Hex(), asOf.Format(time.RFC3339))}
+	}
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(version.Document, document)
+}
+
+// version decodes into document one of the versions of a document.
+func (c *scopedCollection) version(ctx context.Context, id primitive.ObjectID, number int, document interface{}) error {
+	var version storedVersion
+	err := c.sibling(versionsCollection).FindOne(ctx, bson.M{"entity": c.collection.Name(), "entityId": id, "number": number}).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return &NotFoundError{Entity: entityOf(c.collection.Name()), ID: id.Hex(), Message: fmt.Sprintf("no version %d of %s %s", number, entityOf(c.collection.Name()), id.Hex())}
+	}
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(version.Document, document)
+}
+
+// purgeVersions removes the versions of documents purged for good.
+func (c *scopedCollection) purgeVersions(ctx context.Context, ids bson.A) error {
+	if !versionedCollections[c.collection.Name()] {
+		return nil
+	}
+	_, err := c.collection.Database().Collection(versionsCollection).DeleteMany(c.bind(ctx), bson.M{"entity": c.collection.Name(), "entityId": bson.M{"$in": ids}})
+	return err
+}